@@ -3,19 +3,31 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/s0rg/compflag"
 
+	"github.com/s0rg/crawley/internal/client"
 	"github.com/s0rg/crawley/internal/crawler"
+	"github.com/s0rg/crawley/internal/graph"
+	"github.com/s0rg/crawley/internal/rules"
 	"github.com/s0rg/crawley/internal/values"
 )
 
@@ -24,6 +36,17 @@ const (
 	appSite        = "https://github.com/s0rg/crawley"
 	defaultDelay   = 150 * time.Millisecond
 	defaultTimeout = 5 * time.Second
+	defaultSaveIvl = time.Minute
+	outputPlain    = "plain"
+	outputJSONL    = "jsonl"
+)
+
+var (
+	errAuthConflict  = errors.New("both -auth-user and -auth-bearer given")
+	errAuthNoUser    = errors.New("-auth-digest requires -auth-user")
+	errOAuthConflict = errors.New("-oauth-token-url cannot be used with -auth-user or -auth-bearer")
+	errBrokenLinks   = errors.New("broken links found")
+	errCheckResume   = errors.New("-check, -check-external and -check-anchors cannot be used with -checkpoint or -resume")
 )
 
 // build-time values.
@@ -37,17 +60,47 @@ var (
 // command-line flags.
 var (
 	fDepth, fWorkers        int
+	fHops                   int
+	fFrontier               int
+	fMaxPages, fMaxURLs     int
+	fMaxBytes               int64
+	fHostConns, fRetries    int
+	fMaxRedirects           int
+	fHostRate               float64
 	fSilent, fVersion       bool
 	fBrute, fNoHeads        bool
 	fSkipSSL, fScanJS       bool
 	fScanCSS, fScanALL      bool
-	fSubdomains             bool
+	fSubdomains, fNoAvoid   bool
+	fSessionCheck, fChains  bool
+	fCheck, fCheckExternal  bool
+	fCheckAnchors           bool
+	fExternalWorkers        int
+	fExternalRate           float64
 	fDirsPolicy, fProxyAuth string
 	fRobotsPolicy, fUA      string
+	fScopePolicy            string
+	fCheckpoint, fResume    string
+	fCookieJar, fLogin      string
+	fAuthUser, fAuthBearer  string
+	fOAuthURL, fOAuthID     string
+	fOAuthSecret            string
+	fCert, fCertKey, fCA    string
+	fAuthDigest             bool
+	fOutput                 string
+	fGraph, fGraphFormat    string
 	fDelay                  time.Duration
-	fTimeout                time.Duration
+	fTimeout, fSaveEvery    time.Duration
+	fMaxTime, fRetryWait    time.Duration
 	cookies, headers        values.Smart
+	seeds                   values.Smart
+	crawlInc, crawlExc      values.Smart
+	outInc, outExc          values.Smart
+	avoid                   values.Smart
 	tags, ignored           values.List
+	scopeHosts              values.List
+	oauthScopes             values.List
+	authHosts               values.List
 )
 
 func version() string {
@@ -65,7 +118,9 @@ func usage() {
 	var sb strings.Builder
 
 	fmt.Fprintf(&sb, "%s - the unix-way web crawler, usage:\n\n", appName)
-	fmt.Fprintf(&sb, "%s [flags] url\n\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(&sb, "%s [flags] url [url...]\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(&sb, "%s [flags] < urls.txt\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(&sb, "%s [flags] -resume file\n\n", filepath.Base(os.Args[0]))
 	fmt.Fprint(&sb, "possible flags with default values:\n\n")
 
 	_, _ = os.Stderr.WriteString(sb.String())
@@ -77,21 +132,427 @@ func puts(s string) {
 	_, _ = os.Stdout.WriteString(s + "\n")
 }
 
-func crawl(uri string, opts ...crawler.Option) error {
-	c := crawler.New(opts...)
+func printer(format string) (rv crawler.ResultHandler, err error) {
+	switch format {
+	case outputPlain:
+		if fChains {
+			return func(r *crawler.Result) {
+				puts(r.RedirectChain())
+			}, nil
+		}
+
+		return crawler.URLHandler(puts), nil
+	case outputJSONL:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+
+		return func(r *crawler.Result) {
+			_ = enc.Encode(r)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format: %s", format)
+}
+
+func writeGraph(g *graph.Graph) (err error) {
+	format, err := graph.ParseFormat(fGraphFormat)
+	if err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+
+	fd, err := os.Create(fGraph)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	if err = g.Write(fd, format); err != nil {
+		_ = fd.Close()
+
+		return fmt.Errorf("write: %w", err)
+	}
+
+	if err = fd.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	log.Printf("[*] graph: %d nodes, %d edges saved to: %s", g.Nodes(), g.Edges(), fGraph)
+
+	return nil
+}
+
+func loadJar(name string) (j *client.Jar, err error) {
+	j = client.NewJar()
+
+	fd, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return j, nil
+		}
+
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	defer fd.Close()
+
+	if err = j.Load(fd); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	return j, nil
+}
+
+func loadLogin(name string) (l *client.Login, err error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	defer fd.Close()
+
+	if l, err = client.LoadLogin(fd); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	return l, nil
+}
+
+func loadAuth() (a *client.Auth, err error) {
+	switch {
+	case fAuthUser != "" && fAuthBearer != "":
+		return nil, errAuthConflict
+	case fAuthBearer != "":
+		return &client.Auth{Scheme: client.AuthBearer, Token: fAuthBearer}, nil
+	case fAuthUser != "":
+		a = &client.Auth{Scheme: client.AuthBasic}
+		a.User, a.Password, _ = strings.Cut(fAuthUser, ":")
+
+		if fAuthDigest {
+			a.Scheme = client.AuthDigest
+		}
+
+		return a, nil
+	case fAuthDigest:
+		return nil, errAuthNoUser
+	}
+
+	return nil, nil
+}
+
+func loadOAuth() (o *client.OAuth, err error) {
+	switch {
+	case fOAuthURL == "":
+		return nil, nil
+	case fAuthUser != "" || fAuthBearer != "":
+		return nil, errOAuthConflict
+	}
+
+	return &client.OAuth{
+		TokenURL:     fOAuthURL,
+		ClientID:     fOAuthID,
+		ClientSecret: fOAuthSecret,
+		Scopes:       oauthScopes.Values,
+	}, nil
+}
+
+func loadTLS() (cert *tls.Certificate, ca *x509.CertPool, err error) {
+	if fCert != "" {
+		if cert, err = client.LoadCert(fCert, fCertKey); err != nil {
+			return nil, nil, fmt.Errorf("cert: %w", err)
+		}
+	}
+
+	if fCA != "" {
+		if ca, err = client.LoadCA(fCA); err != nil {
+			return nil, nil, fmt.Errorf("ca: %w", err)
+		}
+	}
+
+	return cert, ca, nil
+}
+
+func saveJar(j *client.Jar, name string) (err error) {
+	fd, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	if err = j.Save(fd); err != nil {
+		_ = fd.Close()
+
+		return fmt.Errorf("save: %w", err)
+	}
+
+	if err = fd.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	log.Printf("[*] cookies: %d saved to: %s", j.Len(), name)
+
+	return nil
+}
+
+// loadSession loads login recipe and cookie jar, if given.
+func loadSession() (rv []crawler.Option, jar *client.Jar, err error) {
+	if fLogin != "" {
+		var login *client.Login
+
+		if login, err = loadLogin(fLogin); err != nil {
+			return nil, nil, fmt.Errorf("login: %w", err)
+		}
+
+		rv = append(rv, crawler.WithLogin(login))
+	}
+
+	if fCookieJar != "" {
+		if jar, err = loadJar(fCookieJar); err != nil {
+			return nil, nil, fmt.Errorf("cookie jar: %w", err)
+		}
+
+		rv = append(rv, crawler.WithCookieJar(jar))
+	}
+
+	return rv, jar, nil
+}
+
+func crawl(ctx context.Context, uris []string, opts ...crawler.Option) error {
+	handler, err := printer(fOutput)
+	if err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+
+	sess, jar, err := loadSession()
+	if err != nil {
+		return err
+	}
+
+	if jar != nil {
+		defer func() {
+			if err := saveJar(jar, fCookieJar); err != nil {
+				log.Println("[-] cookie jar:", err)
+			}
+		}()
+	}
+
+	c := crawler.New(append(opts, sess...)...)
+
+	if fResume != "" {
+		if uris, err = c.Restore(fResume); err != nil {
+			return fmt.Errorf("resume: %w", err)
+		}
+
+		log.Printf("[*] resuming from: %s", fResume)
+	}
 
 	log.Printf("[*] config: %s", c.DumpConfig())
-	log.Printf("[*] crawling url: %s", uri)
+	if len(uris) == 1 {
+		log.Printf("[*] crawling url: %s", uris[0])
+	} else {
+		log.Printf("[*] crawling urls: %d", len(uris))
+	}
+
+	err = c.CrawlSeeds(ctx, uris, handler)
+
+	return complete(err, report(c))
+}
+
+// report logs crawl results, other than urls: broken links, failures and graph, returns error,
+// if there are broken links.
+func report(c *crawler.Crawler) (err error) {
+	if fCheck || fCheckExternal || fCheckAnchors {
+		err = reportBroken(c.Broken(), c.BrokenExternal(), c.BrokenAnchors())
+	}
 
-	if err := c.Run(uri, puts); err != nil {
+	if !fCheck {
+		reportFailures(c.Failures())
+	}
+
+	if g := c.Graph(); g != nil {
+		if gerr := writeGraph(g); gerr != nil {
+			log.Println("[-] graph:", gerr)
+		}
+	}
+
+	return err
+}
+
+// complete logs, why crawl was stopped, and returns final error.
+func complete(err, berr error) error {
+	var (
+		cerr crawler.CancelError
+		lerr crawler.LimitError
+		serr crawler.SessionError
+	)
+
+	switch {
+	case errors.As(err, &lerr):
+		log.Printf("[*] complete, stopped by budget: %v", lerr)
+
+		return berr
+	case errors.As(err, &serr):
+		log.Printf("[!] session lost, all found results are flushed")
+
+		if cp := checkpointName(); cp != "" {
+			log.Printf("[*] restore session and continue with: -resume %s", cp)
+		}
+	case errors.As(err, &cerr):
+		log.Printf("[!] interrupted, all found results are flushed")
+	}
+
+	if err != nil {
 		return fmt.Errorf("run: %w", err)
 	}
 
 	log.Printf("[*] complete")
 
+	return berr
+}
+
+func reportFailures(f []crawler.Failure) {
+	if len(f) == 0 {
+		return
+	}
+
+	log.Printf("[!] failed urls: %d", len(f))
+
+	for i := range f {
+		log.Printf("[-] %s: %s (tries: %d)", f[i].URL, f[i].Error, f[i].Tries)
+	}
+}
+
+// reportBroken logs broken links (internal, external and anchors separately), with pages they were
+// found at, returns error, if there are any.
+func reportBroken(internal, external, anchors []crawler.Broken) (err error) {
+	logBroken("broken urls", internal)
+	logBroken("broken external urls", external)
+	logBroken("broken anchors", anchors)
+
+	if n := len(internal) + len(external) + len(anchors); n > 0 {
+		return fmt.Errorf("%w: %d", errBrokenLinks, n)
+	}
+
 	return nil
 }
 
+func logBroken(title string, b []crawler.Broken) {
+	if len(b) == 0 {
+		return
+	}
+
+	log.Printf("[!] %s: %d", title, len(b))
+
+	for i := range b {
+		log.Printf("[-] %s: %s", b[i].URL, b[i].Error)
+
+		for _, s := range b[i].Sources {
+			log.Printf("[-]   found at: %s", s)
+		}
+
+		if n := b[i].Refs - len(b[i].Sources); n > 0 {
+			log.Printf("[-]   found at: %d more pages", n)
+		}
+	}
+}
+
+// loadSeeds collects urls from arguments and -seeds flag, or from stdin, if none given (and not resuming)
+// and stdin is not a terminal.
+func loadSeeds(args []string) (rv []string, err error) {
+	var wd string
+
+	if wd, err = os.Getwd(); err != nil {
+		return nil, fmt.Errorf("work dir: %w", err)
+	}
+
+	vals, err := seeds.Load(os.DirFS(wd))
+	if err != nil {
+		return nil, fmt.Errorf("seeds: %w", err)
+	}
+
+	vals = append(vals, args...)
+
+	if len(vals) == 0 && fResume == "" && !isTerminal(os.Stdin) {
+		sc := bufio.NewScanner(os.Stdin)
+
+		for sc.Scan() {
+			vals = append(vals, sc.Text())
+		}
+
+		if err = sc.Err(); err != nil {
+			return nil, fmt.Errorf("stdin: %w", err)
+		}
+	}
+
+	return skipComments(vals), nil
+}
+
+// skipComments returns trimmed values, dropping blank and commented (starting with '#') ones.
+func skipComments(vals []string) (rv []string) {
+	rv = make([]string, 0, len(vals))
+
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" && !strings.HasPrefix(v, "#") {
+			rv = append(rv, v)
+		}
+	}
+
+	return rv
+}
+
+func isTerminal(fd *os.File) (yes bool) {
+	st, err := fd.Stat()
+	if err != nil {
+		return true
+	}
+
+	return st.Mode()&os.ModeCharDevice != 0
+}
+
+type ruleSet struct {
+	crawlInclude, crawlExclude   []string
+	outputInclude, outputExclude []string
+	avoid                        []string
+}
+
+func loadRules() (rs ruleSet, err error) {
+	var wd string
+
+	if wd, err = os.Getwd(); err != nil {
+		return rs, fmt.Errorf("work dir: %w", err)
+	}
+
+	fs := os.DirFS(wd)
+
+	for _, v := range []struct {
+		src *values.Smart
+		dst *[]string
+	}{
+		{&crawlInc, &rs.crawlInclude},
+		{&crawlExc, &rs.crawlExclude},
+		{&outInc, &rs.outputInclude},
+		{&outExc, &rs.outputExclude},
+		{&avoid, &rs.avoid},
+	} {
+		if *v.dst, err = v.src.Load(fs); err != nil {
+			return rs, fmt.Errorf("load: %w", err)
+		}
+
+		*v.dst = skipComments(*v.dst)
+	}
+
+	if _, err = rules.New(rs.crawlInclude, rs.crawlExclude); err != nil {
+		return rs, fmt.Errorf("crawl: %w", err)
+	}
+
+	if _, err = rules.New(rs.outputInclude, rs.outputExclude); err != nil {
+		return rs, fmt.Errorf("output: %w", err)
+	}
+
+	if _, err = rules.New(nil, rs.avoid); err != nil {
+		return rs, fmt.Errorf("avoid: %w", err)
+	}
+
+	return rs, nil
+}
+
 func loadSmart() (h, c []string, err error) {
 	var wd string
 
@@ -118,28 +579,124 @@ func loadSmart() (h, c []string, err error) {
 	return h, c, nil
 }
 
-func parseFlags() (rv []crawler.Option, err error) {
+// checkpointName returns file name to save crawl state to, resumed checkpoint is updated, if no other given.
+func checkpointName() (rv string) {
+	if fCheckpoint != "" {
+		return fCheckpoint
+	}
+
+	return fResume
+}
+
+func parsePolicies() (rv []crawler.Option, err error) {
 	robots, err := crawler.ParseRobotsPolicy(fRobotsPolicy)
 	if err != nil {
-		err = fmt.Errorf("robots policy: %w", err)
-
-		return
+		return nil, fmt.Errorf("robots policy: %w", err)
 	}
 
 	dirs, err := crawler.ParseDirsPolicy(fDirsPolicy)
 	if err != nil {
-		err = fmt.Errorf("dirs policy: %w", err)
+		return nil, fmt.Errorf("dirs policy: %w", err)
+	}
 
-		return
+	scope, err := crawler.ParseScopePolicy(fScopePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("scope policy: %w", err)
 	}
 
+	rv = []crawler.Option{
+		crawler.WithDirsPolicy(dirs),
+		crawler.WithRobotsPolicy(robots),
+		crawler.WithScopePolicy(scope),
+		crawler.WithScopeHosts(scopeHosts.Values),
+		crawler.WithSubdomains(fSubdomains),
+	}
+
+	return rv, nil
+}
+
+// loadCredentials loads extra headers and cookies, http auth and tls settings.
+func loadCredentials() (rv []crawler.Option, err error) {
 	uheaders, ucookies, err := loadSmart()
 	if err != nil {
-		err = fmt.Errorf("load: %w", err)
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	auth, err := loadAuth()
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	oauth, err := loadOAuth()
+	if err != nil {
+		return nil, fmt.Errorf("oauth: %w", err)
+	}
+
+	cert, ca, err := loadTLS()
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+
+	rv = []crawler.Option{
+		crawler.WithExtraHeaders(uheaders),
+		crawler.WithExtraCookies(ucookies),
+		crawler.WithProxyAuth(fProxyAuth),
+		crawler.WithAuth(auth),
+		crawler.WithOAuth(oauth),
+		crawler.WithAuthHosts(authHosts.Values),
+		crawler.WithClientCert(cert),
+		crawler.WithRootCAs(ca),
+		crawler.WithSessionCheck(fSessionCheck),
+	}
+
+	return rv, nil
+}
+
+func checkOptions() []crawler.Option {
+	return []crawler.Option{
+		crawler.WithCheck(fCheck),
+		crawler.WithCheckExternal(fCheckExternal),
+		crawler.WithCheckAnchors(fCheckAnchors),
+		crawler.WithExternalWorkers(fExternalWorkers),
+		crawler.WithExternalRate(fExternalRate),
+	}
+}
+
+func limitOptions() []crawler.Option {
+	return []crawler.Option{
+		crawler.WithMaxCrawlDepth(fDepth),
+		crawler.WithMaxHops(fHops),
+		crawler.WithMaxPages(fMaxPages),
+		crawler.WithMaxURLs(fMaxURLs),
+		crawler.WithMaxBytes(fMaxBytes),
+		crawler.WithMaxDuration(fMaxTime),
+	}
+}
+
+func parseFlags() (rv []crawler.Option, err error) {
+	if _, err = graph.ParseFormat(fGraphFormat); err != nil {
+		err = fmt.Errorf("graph format: %w", err)
 
 		return
 	}
 
+	policies, err := parsePolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := loadRules()
+	if err != nil {
+		err = fmt.Errorf("rules: %w", err)
+
+		return
+	}
+
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+
 	scanJS, scanCSS := fScanJS, fScanCSS
 
 	if fScanALL {
@@ -149,60 +706,165 @@ func parseFlags() (rv []crawler.Option, err error) {
 	rv = []crawler.Option{
 		crawler.WithUserAgent(fUA),
 		crawler.WithDelay(fDelay),
-		crawler.WithMaxCrawlDepth(fDepth),
+		crawler.WithHostRate(fHostRate),
+		crawler.WithHostConns(fHostConns),
+		crawler.WithMaxRedirects(fMaxRedirects),
 		crawler.WithWorkersCount(fWorkers),
 		crawler.WithSkipSSL(fSkipSSL),
 		crawler.WithBruteMode(fBrute),
-		crawler.WithDirsPolicy(dirs),
-		crawler.WithRobotsPolicy(robots),
 		crawler.WithoutHeads(fNoHeads),
 		crawler.WithScanJS(scanJS),
 		crawler.WithScanCSS(scanCSS),
-		crawler.WithExtraHeaders(uheaders),
-		crawler.WithExtraCookies(ucookies),
 		crawler.WithTagsFilter(tags.Values),
 		crawler.WithIgnored(ignored.Values),
-		crawler.WithProxyAuth(fProxyAuth),
+		crawler.WithCrawlRules(rs.crawlInclude, rs.crawlExclude),
+		crawler.WithOutputRules(rs.outputInclude, rs.outputExclude),
+		crawler.WithAvoid(rs.avoid),
+		crawler.WithoutAvoid(fNoAvoid),
 		crawler.WithTimeout(fTimeout),
-		crawler.WithSubdomains(fSubdomains),
+		crawler.WithRetries(fRetries),
+		crawler.WithRetryWait(fRetryWait),
+		crawler.WithCheckpoint(checkpointName()),
+		crawler.WithCheckpointInterval(fSaveEvery),
+		crawler.WithFrontierSize(fFrontier),
+		crawler.WithLinkGraph(fGraph != ""),
 	}
 
-	return rv, nil
+	return slices.Concat(rv, policies, creds, checkOptions(), limitOptions()), nil
 }
 
-func setupFlags() {
-	flag.Var(&headers, "header",
-		"extra headers for request, can be used multiple times, accept files with '@'-prefix",
+// setupFilterFlags sets flags, that control which urls are crawled and printed.
+func setupFilterFlags() {
+	flag.Var(&seeds, "seeds",
+		"extra urls to crawl, can be used multiple times, accept files with '@'-prefix",
 	)
-	flag.Var(&cookies, "cookie",
-		"extra cookies for request, can be used multiple times, accept files with '@'-prefix",
+	flag.Var(&crawlInc, "crawl-include",
+		"only crawl urls, matching any of patterns ('re:' regex or glob), "+
+			"can be used multiple times, accept files with '@'-prefix",
 	)
-
+	flag.Var(&crawlExc, "crawl-exclude",
+		"do not crawl urls, matching any of patterns ('re:' regex or glob), "+
+			"can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.Var(&outInc, "include",
+		"only print urls, matching any of patterns ('re:' regex or glob), "+
+			"can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.Var(&outExc, "exclude",
+		"do not print urls, matching any of patterns ('re:' regex or glob), "+
+			"can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.Var(&avoid, "avoid",
+		"do not crawl urls, that can end session ('re:' regex or glob), "+
+			"replaces built-in patterns, can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.BoolVar(&fNoAvoid, "no-avoid", false, "disable built-in avoidance of logout links")
 	flag.Var(&tags, "tag", "tags filter, single or comma-separated tag names")
 	flag.Var(&ignored, "ignore", "patterns (in urls) to be ignored in crawl process")
+	flag.Var(&scopeHosts, "scope-host", "extra hosts to crawl with 'list' scope, single or comma-separated")
+	flag.BoolVar(&fSubdomains, "subdomains", false, "Support subdomains (e.g. if www.domain.com found, recurse over it)")
+	flag.StringVar(&fDirsPolicy, "dirs", crawler.DefaultDirsPolicy,
+		"policy for non-resource urls: show / hide / only")
+	flag.StringVar(&fRobotsPolicy, "robots", crawler.DefaultRobotsPolicy,
+		"policy for robots.txt: ignore / crawl / respect")
+	flag.StringVar(&fScopePolicy, "scope", crawler.DefaultScopePolicy,
+		"crawl scope: path / host / domain / list")
+}
 
+// setupCrawlFlags sets flags, that control how urls are crawled.
+func setupCrawlFlags() {
 	flag.IntVar(&fDepth, "depth", 0, "scan depth (set -1 for unlimited)")
+	flag.IntVar(&fHops, "hops", 0, "max link hops from starting url, checked along with depth (0 - unlimited)")
 	flag.IntVar(&fWorkers, "workers", runtime.NumCPU(), "number of workers")
-
+	flag.IntVar(&fFrontier, "frontier-size", crawler.DefaultFrontierSize,
+		"max crawl queue size in memory, the rest is spilled to temporary file")
+	flag.IntVar(&fHostConns, "host-conns", 0, "max concurrent requests per host (0 - unlimited)")
+	flag.Float64Var(&fHostRate, "host-rate", 0, "max requests per second per host, fractions allowed (0 - unlimited)")
+	flag.IntVar(&fMaxRedirects, "max-redirects", client.DefaultMaxRedirects,
+		"max redirects to follow for single request, only in-scope ones are followed (-1 - do not follow)")
+	flag.IntVar(&fRetries, "retries", crawler.DefaultRetries,
+		"max retries for requests, failed due to network or server errors (0 - disable)")
+	flag.DurationVar(&fRetryWait, "retry-wait", crawler.DefaultRetryWait,
+		"base interval between retries, doubled (and jittered) for every try")
+	flag.DurationVar(&fDelay, "delay", defaultDelay, "per-request delay (0 - disable)")
+	flag.DurationVar(&fTimeout, "timeout", defaultTimeout, "request timeout (min: 1 second, max: 10 minutes)")
 	flag.BoolVar(&fScanALL, "all", false, "scan all known sources (js/css/...)")
 	flag.BoolVar(&fBrute, "brute", false, "scan html comments")
-	flag.BoolVar(&fSubdomains, "subdomains", false, "Support subdomains (e.g. if www.domain.com found, recurse over it)")
 	flag.BoolVar(&fScanCSS, "css", false, "scan css for urls")
-	flag.BoolVar(&fNoHeads, "headless", false, "disable pre-flight HEAD requests")
 	flag.BoolVar(&fScanJS, "js", false, "scan js code for endpoints")
+	flag.BoolVar(&fNoHeads, "headless", false, "disable pre-flight HEAD requests")
 	flag.BoolVar(&fSkipSSL, "skip-ssl", false, "skip ssl verification")
-	flag.BoolVar(&fSilent, "silent", false, "suppress info and error messages in stderr")
-	flag.BoolVar(&fVersion, "version", false, "show version")
-
-	flag.StringVar(&fDirsPolicy, "dirs", crawler.DefaultDirsPolicy,
-		"policy for non-resource urls: show / hide / only")
-	flag.StringVar(&fRobotsPolicy, "robots", crawler.DefaultRobotsPolicy,
-		"policy for robots.txt: ignore / crawl / respect")
 	flag.StringVar(&fUA, "user-agent", defaultUA, "user-agent string")
+}
+
+// setupAuthFlags sets flags for credentials and session handling.
+func setupAuthFlags() {
+	flag.Var(&headers, "header",
+		"extra headers for request, can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.Var(&cookies, "cookie",
+		"extra cookies for request, can be used multiple times, accept files with '@'-prefix",
+	)
+	flag.StringVar(&fCookieJar, "cookie-jar", "",
+		"file with cookies in Netscape format, to load session from and save it to, after crawl")
+	flag.StringVar(&fLogin, "login", "", "json file with login recipe, to run before crawl (and again, on session loss)")
+	flag.BoolVar(&fSessionCheck, "session-check", false,
+		"pause crawl on session loss: redirect to login page, or same short responses in a row")
 	flag.StringVar(&fProxyAuth, "proxy-auth", "", "credentials for proxy: user:password")
+	flag.StringVar(&fAuthUser, "auth-user", "", "credentials for http authentication (basic or digest): user:password")
+	flag.StringVar(&fAuthBearer, "auth-bearer", "", "token for http bearer authentication")
+	flag.BoolVar(&fAuthDigest, "auth-digest", false, "use digest scheme for -auth-user credentials")
+	flag.Var(&authHosts, "auth-host",
+		"extra hosts to send credentials to (besides starting ones), single or comma-separated")
+	flag.StringVar(&fOAuthURL, "oauth-token-url", "",
+		"oauth2 token endpoint, to obtain bearer token from, with client credentials")
+	flag.StringVar(&fOAuthID, "oauth-client-id", "", "oauth2 client id")
+	flag.StringVar(&fOAuthSecret, "oauth-client-secret", "", "oauth2 client secret")
+	flag.Var(&oauthScopes, "oauth-scope", "scopes to request oauth token for, single or comma-separated")
+	flag.StringVar(&fCert, "cert", "", "client certificate file (PEM), for mTLS")
+	flag.StringVar(&fCertKey, "cert-key", "", "client certificate key file (PEM), if not stored along with certificate")
+	flag.StringVar(&fCA, "ca-cert", "", "CA bundle file (PEM), to verify servers with, in addition to system ones")
+}
 
-	flag.DurationVar(&fDelay, "delay", defaultDelay, "per-request delay (0 - disable)")
-	flag.DurationVar(&fTimeout, "timeout", defaultTimeout, "request timeout (min: 1 second, max: 10 minutes)")
+// setupCheckFlags sets flags for links checks and crawl budgets.
+func setupCheckFlags() {
+	flag.BoolVar(&fCheck, "check", false,
+		"check every found url (including static and external ones) and report broken ones, exit with error, if any")
+	flag.BoolVar(&fCheckAnchors, "check-anchors", false,
+		"check links to anchors (i.e. page.html#install) on crawled pages and report missing ones, exit with error, if any")
+	flag.BoolVar(&fCheckExternal, "check-external", false,
+		"check links to out-of-scope hosts (once per url, "+
+			"without crawling them) and report broken ones, exit with error, if any")
+	flag.IntVar(&fExternalWorkers, "external-workers", crawler.DefaultExternalWorkers,
+		"number of workers for external links check")
+	flag.Float64Var(&fExternalRate, "external-rate", crawler.DefaultExternalRate,
+		"max requests per second per external host, fractions allowed (0 - unlimited)")
+	flag.IntVar(&fMaxPages, "max-pages", 0, "stop after given count of fetched pages (0 - unlimited)")
+	flag.IntVar(&fMaxURLs, "max-urls", 0, "stop after given count of printed urls (0 - unlimited)")
+	flag.Int64Var(&fMaxBytes, "max-bytes", 0, "stop after given total size of read responses, in bytes (0 - unlimited)")
+	flag.DurationVar(&fMaxTime, "max-time", 0, "stop after given crawl duration (0 - unlimited)")
+}
+
+// setupOutputFlags sets flags for output and crawl state.
+func setupOutputFlags() {
+	flag.StringVar(&fOutput, "output", outputPlain, "output format: plain / jsonl")
+	flag.BoolVar(&fChains, "redirects", false, "show redirect chains in plain output, as: url -> 301 -> target")
+	flag.StringVar(&fGraph, "graph", "", "file to save links graph to, after crawl")
+	flag.StringVar(&fGraphFormat, "graph-format", graph.DefaultFormat, "links graph format: dot / graphml / json")
+	flag.StringVar(&fCheckpoint, "checkpoint", "", "file to save crawl state to, on exit and periodically")
+	flag.DurationVar(&fSaveEvery, "checkpoint-every", defaultSaveIvl, "checkpoint save interval (0 - only on exit)")
+	flag.StringVar(&fResume, "resume", "",
+		"continue crawl from checkpoint file (saves progress to it, if no -checkpoint given)")
+	flag.BoolVar(&fSilent, "silent", false, "suppress info and error messages in stderr")
+	flag.BoolVar(&fVersion, "version", false, "show version")
+}
+
+func setupFlags() {
+	setupFilterFlags()
+	setupCrawlFlags()
+	setupAuthFlags()
+	setupCheckFlags()
+	setupOutputFlags()
 
 	flag.Usage = usage
 }
@@ -222,12 +884,23 @@ func main() {
 		return
 	}
 
-	if flag.NArg() != 1 {
+	uris, err := loadSeeds(flag.Args())
+	if err != nil {
+		log.Fatal("[-] urls:", err)
+	}
+
+	// urls are either given, or restored from checkpoint
+	if (fResume == "") == (len(uris) == 0) {
 		usage()
 
 		return
 	}
 
+	// broken links (and pages, they were found at) are not saved to checkpoint
+	if (fCheck || fCheckExternal || fCheckAnchors) && (fCheckpoint != "" || fResume != "") {
+		log.Fatal("[-] options:", errCheckResume)
+	}
+
 	opts, err := parseFlags()
 	if err != nil {
 		log.Fatal("[-] options:", err)
@@ -237,7 +910,18 @@ func main() {
 		log.SetOutput(io.Discard)
 	}
 
-	if err := crawl(flag.Arg(0), opts...); err != nil {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	// first signal stops crawl gracefully, signals handling is restored then, so second one kills process
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
+	if err = crawl(ctx, uris, opts...); err != nil {
+		cancel()
+
 		// forcing back stderr in case of errors, otherwise, if 'silent' is on - no one will knows what happened.
 		log.SetOutput(os.Stderr)
 		log.Fatal("[-] crawler:", err)
@@ -8,3 +8,5 @@ require (
 	github.com/tdewolff/parse/v2 v2.8.11
 	golang.org/x/net v0.53.0
 )
+
+require golang.org/x/text v0.36.0 // indirect
@@ -0,0 +1,310 @@
+package client
+
+import (
+	"crypto/md5" //nolint:gosec // md5 is a default digest algorithm
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/s0rg/set"
+)
+
+const (
+	schemeDigest = "Digest"
+	schemeBearer = "Bearer"
+
+	headerAuthorize = "Authorization"
+	headerChallenge = "WWW-Authenticate"
+
+	qopAuth    = "auth"
+	algMD5     = "MD5"
+	algSHA256  = "SHA-256"
+	sessSuffix = "-SESS"
+	cnonceSize = 8
+	kvParts    = 2
+)
+
+// ErrBadChallenge is returned for digest challenges, that cannot be answered.
+var ErrBadChallenge = errors.New("bad digest challenge")
+
+// AuthScheme is a http authentication scheme.
+type AuthScheme byte
+
+// Authentication schemes.
+const (
+	AuthBasic AuthScheme = iota
+	AuthDigest
+	AuthBearer
+)
+
+// String returns scheme name.
+func (s AuthScheme) String() string {
+	switch s {
+	case AuthDigest:
+		return "digest"
+	case AuthBearer:
+		return "bearer"
+	}
+
+	return "basic"
+}
+
+// Auth holds credentials for http authentication, they are sent with every request to allowed hosts.
+type Auth struct {
+	User     string
+	Password string
+	Token    string // for bearer scheme
+	Scheme   AuthScheme
+}
+
+// authorizer applies credentials to requests, for digest scheme it keeps last challenge for every host.
+type authorizer struct {
+	auth    *Auth
+	digests map[string]*digest
+	mu      sync.Mutex
+}
+
+func newAuthorizer(a *Auth) (rv *authorizer) {
+	if a == nil {
+		return nil
+	}
+
+	return &authorizer{
+		auth:    a,
+		digests: make(map[string]*digest),
+	}
+}
+
+// Authorize sets credentials for request, it is nil-safe.
+func (a *authorizer) Authorize(req *http.Request) {
+	if a == nil {
+		return
+	}
+
+	switch a.auth.Scheme {
+	case AuthBasic:
+		req.SetBasicAuth(a.auth.User, a.auth.Password)
+	case AuthBearer:
+		req.Header.Set(headerAuthorize, schemeBearer+" "+a.auth.Token)
+	case AuthDigest:
+		a.mu.Lock()
+		d, ok := a.digests[req.URL.Host]
+		a.mu.Unlock()
+
+		if ok {
+			req.Header.Set(headerAuthorize, d.Authorize(req.Method, req.URL.RequestURI(), a.auth))
+		}
+	}
+}
+
+// Challenge accepts digest challenge from response, returns true, if request should be sent again.
+func (a *authorizer) Challenge(resp *http.Response) (yes bool) {
+	if a == nil || a.auth.Scheme != AuthDigest || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	for _, v := range resp.Header.Values(headerChallenge) {
+		d, err := parseChallenge(v)
+		if err != nil {
+			continue
+		}
+
+		// credentials were sent and rejected, unless nonce is just stale
+		if resp.Request.Header.Get(headerAuthorize) != "" && !d.stale {
+			return false
+		}
+
+		a.mu.Lock()
+		a.digests[resp.Request.URL.Host] = d
+		a.mu.Unlock()
+
+		return true
+	}
+
+	return false
+}
+
+// authScope holds hosts, auth credentials are sent to, empty scope allows any host.
+type authScope struct {
+	hosts set.Set[string]
+}
+
+func newAuthScope(hosts []string) (rv *authScope) {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	rv = &authScope{hosts: make(set.Unordered[string])}
+
+	for _, h := range hosts {
+		rv.hosts.Add(strings.ToLower(h))
+	}
+
+	return rv
+}
+
+// Allow reports, if credentials can be sent to u, it is nil-safe.
+func (s *authScope) Allow(u *url.URL) (yes bool) {
+	if s == nil {
+		return true
+	}
+
+	return s.hosts.Has(strings.ToLower(u.Host)) || s.hosts.Has(strings.ToLower(u.Hostname()))
+}
+
+// digest holds parameters of digest challenge (RFC 7616).
+type digest struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string // as given by server
+	qop       bool
+	stale     bool
+	mu        sync.Mutex
+	nc        uint32
+}
+
+func parseChallenge(v string) (d *digest, err error) {
+	scheme, params, _ := strings.Cut(strings.TrimSpace(v), " ")
+	if !strings.EqualFold(scheme, schemeDigest) {
+		return nil, fmt.Errorf("%w: scheme: %s", ErrBadChallenge, scheme)
+	}
+
+	d = &digest{algorithm: algMD5}
+
+	for k, v := range parseParams(params) {
+		switch k {
+		case "realm":
+			d.realm = v
+		case "nonce":
+			d.nonce = v
+		case "opaque":
+			d.opaque = v
+		case "algorithm":
+			d.algorithm = v
+		case "stale":
+			d.stale = strings.EqualFold(v, "true")
+		case "qop":
+			for q := range strings.SplitSeq(v, ",") {
+				if strings.TrimSpace(q) == qopAuth {
+					d.qop = true
+				}
+			}
+
+			if !d.qop {
+				return nil, fmt.Errorf("%w: qop: %s", ErrBadChallenge, v)
+			}
+		}
+	}
+
+	if d.nonce == "" {
+		return nil, fmt.Errorf("%w: no nonce", ErrBadChallenge)
+	}
+
+	switch strings.TrimSuffix(strings.ToUpper(d.algorithm), sessSuffix) {
+	case algMD5, algSHA256:
+	default:
+		return nil, fmt.Errorf("%w: algorithm: %s", ErrBadChallenge, d.algorithm)
+	}
+
+	return d, nil
+}
+
+// Authorize returns Authorization header value for given request.
+func (d *digest) Authorize(method, uri string, a *Auth) (rv string) {
+	d.mu.Lock()
+	d.nc++
+	nc := fmt.Sprintf("%08x", d.nc)
+	d.mu.Unlock()
+
+	var (
+		cnonce = newCnonce()
+		h      = d.hash
+		ha1    = h(a.User, d.realm, a.Password)
+		ha2    = h(method, uri)
+		resp   string
+	)
+
+	if strings.HasSuffix(strings.ToUpper(d.algorithm), sessSuffix) {
+		ha1 = h(ha1, d.nonce, cnonce)
+	}
+
+	if d.qop {
+		resp = h(ha1, d.nonce, nc, cnonce, qopAuth, ha2)
+	} else {
+		resp = h(ha1, d.nonce, ha2)
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, `%s username="%s", realm="%s", nonce="%s", uri="%s", algorithm=%s, response="%s"`,
+		schemeDigest, a.User, d.realm, d.nonce, uri, d.algorithm, resp)
+
+	if d.opaque != "" {
+		fmt.Fprintf(&sb, `, opaque="%s"`, d.opaque)
+	}
+
+	if d.qop {
+		fmt.Fprintf(&sb, `, qop=%s, nc=%s, cnonce="%s"`, qopAuth, nc, cnonce)
+	}
+
+	return sb.String()
+}
+
+func (d *digest) hash(parts ...string) (rv string) {
+	var h hash.Hash
+
+	if strings.HasPrefix(strings.ToUpper(d.algorithm), algSHA256) {
+		h = sha256.New()
+	} else {
+		h = md5.New() //nolint:gosec // md5 is a default digest algorithm
+	}
+
+	h.Write([]byte(strings.Join(parts, ":")))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newCnonce() (rv string) {
+	b := make([]byte, cnonceSize)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// parseParams parses comma-separated key=value pairs, values can be quoted (and have commas inside).
+func parseParams(s string) (rv map[string]string) {
+	rv = make(map[string]string)
+
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, ", ") {
+		kv := strings.SplitN(s, "=", kvParts)
+		if len(kv) != kvParts {
+			break
+		}
+
+		key, val := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+
+		if strings.HasPrefix(val, `"`) {
+			end := strings.Index(val[1:], `"`)
+			if end < 0 {
+				break
+			}
+
+			rv[key], s = val[1:end+1], val[end+2:]
+
+			continue
+		}
+
+		rv[key], s, _ = strings.Cut(val, ",")
+		rv[key] = strings.TrimSpace(rv[key])
+	}
+
+	return rv
+}
@@ -0,0 +1,331 @@
+package client
+
+import (
+	"crypto/md5" //nolint:gosec // md5 is a default digest algorithm
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testUser  = "Mufasa"
+	testPass  = "Circle Of Life"
+	testRealm = "testrealm@host.com"
+)
+
+func md5hex(parts ...string) string {
+	sum := md5.Sum([]byte(strings.Join(parts, ":"))) //nolint:gosec // md5 is a default digest algorithm
+
+	return hex.EncodeToString(sum[:])
+}
+
+// digestServer is a minimal RFC 7616 server, with MD5 and qop=auth.
+type digestServer struct {
+	mu    sync.Mutex
+	nonce string
+	hits  atomic.Int32
+	stale bool // nonce was rotated
+}
+
+func (ds *digestServer) rotate() {
+	ds.mu.Lock()
+	ds.nonce += "x"
+	ds.stale = true
+	ds.mu.Unlock()
+}
+
+func (ds *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ds.hits.Add(1)
+
+	ds.mu.Lock()
+	nonce, stale := ds.nonce, ds.stale
+	ds.mu.Unlock()
+
+	if v, ok := strings.CutPrefix(r.Header.Get(headerAuthorize), schemeDigest+" "); ok {
+		p := parseParams(v)
+
+		ha1 := md5hex(p["username"], testRealm, testPass)
+		ha2 := md5hex(r.Method, p["uri"])
+
+		if p["nonce"] == nonce && p["opaque"] == "op" && p["uri"] == r.URL.RequestURI() &&
+			p["response"] == md5hex(ha1, nonce, p["nc"], p["cnonce"], p["qop"], ha2) {
+			return
+		}
+	}
+
+	w.Header().Add(headerChallenge, `Basic realm="other"`)
+	w.Header().Add(headerChallenge, fmt.Sprintf(
+		`Digest realm="%s", qop="auth,auth-int", nonce="%s", opaque="op", stale=%v`,
+		testRealm, nonce, stale,
+	))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func TestParseParams(t *testing.T) {
+	t.Parallel()
+
+	got := parseParams(`realm="a, b", qop="auth,auth-int" , stale=FALSE,nonce="", bad`)
+
+	for k, v := range map[string]string{
+		"realm": "a, b",
+		"qop":   "auth,auth-int",
+		"stale": "FALSE",
+		"nonce": "",
+	} {
+		if got[k] != v {
+			t.Errorf("%s: want: %q got: %q", k, v, got[k])
+		}
+	}
+
+	if len(got) != 4 {
+		t.Error("unexpected params:", got)
+	}
+
+	if got = parseParams(`realm="open`); len(got) != 0 {
+		t.Error("unterminated quote:", got)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	t.Parallel()
+
+	d, err := parseChallenge(`digest nonce="n", algorithm=sha-256-sess, stale=true`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.algorithm != "sha-256-sess" || !d.stale || d.qop {
+		t.Error("unexpected digest:", d)
+	}
+
+	for _, v := range []string{
+		`Basic realm="a"`,
+		`Digest realm="a"`,
+		`Digest nonce="n", algorithm=SHA-512`,
+		`Digest nonce="n", qop="auth-int"`,
+	} {
+		if _, err = parseChallenge(v); !errors.Is(err, ErrBadChallenge) {
+			t.Errorf("%s: unexpected error: %v", v, err)
+		}
+	}
+}
+
+func TestDigestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	// RFC 2069 example, without qop
+	a := &Auth{User: testUser, Password: "CircleOfLife"}
+	d := &digest{realm: testRealm, nonce: "dcd98b7102dd2f0e8b11d0f600bfb0c093", algorithm: algMD5}
+
+	v := d.Authorize(http.MethodGet, "/dir/index.html", a)
+	if !strings.Contains(v, `response="1949323746fe6a43ef61f9606e7febea"`) || strings.Contains(v, "qop") {
+		t.Error("unexpected header:", v)
+	}
+
+	for _, alg := range []string{"MD5-sess", "SHA-256", "SHA-256-sess"} {
+		d = &digest{realm: testRealm, nonce: "n", algorithm: alg, qop: true}
+
+		v = d.Authorize(http.MethodGet, "/", a)
+		p := parseParams(strings.TrimPrefix(v, schemeDigest+" "))
+
+		if p["nc"] != "00000001" || p["algorithm"] != alg || len(p["response"]) == 0 {
+			t.Errorf("%s: unexpected header: %s", alg, v)
+		}
+	}
+}
+
+func TestHTTPAuthDigest(t *testing.T) {
+	t.Parallel()
+
+	ds := &digestServer{nonce: "n"}
+	ts := httptest.NewServer(ds)
+
+	defer ts.Close()
+
+	tc := cfg
+	tc.Auth = &Auth{Scheme: AuthDigest, User: testUser, Password: testPass}
+
+	c := New(&tc)
+
+	if _, err := c.Head(t.Context(), ts.URL+"/a?b=c"); err != nil {
+		t.Fatal("first:", err)
+	}
+
+	if n := ds.hits.Swap(0); n != 2 {
+		t.Error("first - unexpected hits:", n)
+	}
+
+	// challenge is remembered
+	if _, err := c.Head(t.Context(), ts.URL+"/b"); err != nil {
+		t.Fatal("second:", err)
+	}
+
+	if n := ds.hits.Swap(0); n != 1 {
+		t.Error("second - unexpected hits:", n)
+	}
+
+	ds.rotate()
+
+	if _, err := c.Head(t.Context(), ts.URL+"/c"); err != nil {
+		t.Fatal("stale:", err)
+	}
+
+	if n := ds.hits.Swap(0); n != 2 {
+		t.Error("stale - unexpected hits:", n)
+	}
+
+	tc.Auth = &Auth{Scheme: AuthDigest, User: testUser, Password: "bad"}
+
+	if _, err := New(&tc).Head(t.Context(), ts.URL+"/"); err == nil {
+		t.Error("bad password - no error")
+	}
+
+	if n := ds.hits.Swap(0); n != 2 {
+		t.Error("bad password - unexpected hits:", n)
+	}
+}
+
+func TestHTTPAuthBasicBearer(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); ok && u == testUser && p == testPass {
+			return
+		}
+
+		if r.Header.Get(headerAuthorize) == "Bearer token" {
+			return
+		}
+
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	defer ts.Close()
+
+	tc := cfg
+
+	if _, err := New(&tc).Head(t.Context(), ts.URL); err == nil {
+		t.Error("none - no error")
+	}
+
+	for _, a := range []*Auth{
+		{Scheme: AuthBasic, User: testUser, Password: testPass},
+		{Scheme: AuthBearer, Token: "token"},
+	} {
+		tc.Auth = a
+
+		if _, err := New(&tc).Head(t.Context(), ts.URL); err != nil {
+			t.Errorf("%s: unexpected error: %v", a.Scheme, err)
+		}
+	}
+}
+
+func TestAuthScheme(t *testing.T) {
+	t.Parallel()
+
+	for s, want := range map[AuthScheme]string{
+		AuthBasic:  "basic",
+		AuthDigest: "digest",
+		AuthBearer: "bearer",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("want: %s got: %s", want, got)
+		}
+	}
+}
+
+func TestHTTPAuthHosts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		foreign = &digestServer{nonce: "n"}
+		leaked  atomic.Int32
+	)
+
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerAuthorize) != "" {
+			leaked.Add(1)
+		}
+
+		foreign.ServeHTTP(w, r)
+	}))
+
+	defer fs.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerAuthorize) != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	defer ts.Close()
+
+	own, _ := url.Parse(ts.URL)
+
+	tc := cfg
+	tc.AuthHosts = []string{strings.ToUpper(own.Host)}
+
+	for _, a := range []*Auth{
+		{Scheme: AuthBearer, Token: "token"},
+		{Scheme: AuthDigest, User: testUser, Password: testPass},
+	} {
+		tc.Auth = a
+
+		c := New(&tc)
+
+		if a.Scheme == AuthBearer {
+			if _, err := c.Head(t.Context(), ts.URL); err != nil {
+				t.Error("allowed host:", err)
+			}
+		}
+
+		if _, err := c.Head(t.Context(), fs.URL); err == nil {
+			t.Errorf("%s: foreign host - no error", a.Scheme)
+		}
+	}
+
+	if n := leaked.Load(); n != 0 {
+		t.Error("credentials sent to foreign host:", n)
+	}
+
+	// digest challenge is not answered
+	if n := foreign.hits.Load(); n != 2 {
+		t.Error("unexpected foreign hits:", n)
+	}
+}
+
+func TestAuthScope(t *testing.T) {
+	t.Parallel()
+
+	var nilScope *authScope
+
+	u, _ := url.Parse("http://any.host/")
+
+	if !nilScope.Allow(u) || newAuthScope(nil) != nil {
+		t.Error("empty scope")
+	}
+
+	s := newAuthScope([]string{"Site.com", "api.site.com:8080"})
+
+	for uri, want := range map[string]bool{
+		"http://site.com/":           true,
+		"https://SITE.com:8443/a":    true,
+		"http://api.site.com:8080/":  true,
+		"http://api.site.com/":       false,
+		"http://other.com/":          false,
+		"http://site.com.other.com/": false,
+	} {
+		u, _ := url.Parse(uri)
+
+		if got := s.Allow(u); got != want {
+			t.Errorf("%s: want: %t got: %t", uri, want, got)
+		}
+	}
+}
@@ -1,12 +1,38 @@
 package client
 
-import "time"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"slices"
+	"time"
+)
 
 type Config struct {
-	UserAgent string
-	Headers   []string
-	Cookies   []string
-	Workers   int
-	Timeout   time.Duration
-	SkipSSL   bool
+	Jar          *Jar             `json:"-"`
+	Login        *Login           `json:"-"`
+	Auth         *Auth            `json:"-"`
+	OAuth        *OAuth           `json:"-"`
+	Cert         *tls.Certificate `json:"-"` // client certificate, for mTLS
+	RootCAs      *x509.CertPool   `json:"-"` // custom CA bundle, system one is used, if nil
+	UserAgent    string
+	AuthHosts    []string `json:"-"` // hosts (as host, or host:port), auth credentials are sent to, any host if empty
+	Headers      []string
+	ProxyAuth    string `json:"-"` // proxy credentials, as "Proxy-Authorization: ..." header
+	Cookies      []string
+	Workers      int
+	HostConns    int     // max concurrent requests per host, 0 - unlimited
+	HostRate     float64 // max requests per second per host, 0 - unlimited
+	MaxRedirects int     // max redirects per request, 0 - default, negative - do not follow
+	Timeout      time.Duration
+	SkipSSL      bool
+	SessionCheck bool
+}
+
+// allHeaders returns extra headers, along with proxy credentials (if any).
+func (c *Config) allHeaders() (rv []string) {
+	if c.ProxyAuth == "" {
+		return c.Headers
+	}
+
+	return append(slices.Clip(c.Headers), c.ProxyAuth)
 }
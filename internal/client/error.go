@@ -10,19 +10,9 @@ type HTTPError struct {
 	code int
 }
 
-// ErrFromResp creates new HTTPError from response.
+// ErrFromResp creates new HTTPError from response, keeping its status code.
 func ErrFromResp(resp *http.Response) (err error) {
-	code := resp.StatusCode
-
-	// reduce possible statuses to only two - 400 or 500
-	switch {
-	case code >= http.StatusInternalServerError:
-		code = http.StatusInternalServerError
-	case code >= http.StatusBadRequest:
-		code = http.StatusBadRequest
-	}
-
-	return HTTPError{code: code, msg: resp.Status}
+	return HTTPError{code: resp.StatusCode, msg: resp.Status}
 }
 
 // Error return error textual representation.
@@ -42,7 +42,7 @@ func TestHTTPErrorFromResponse(t *testing.T) {
 		t.Error("1: unexpected error")
 	}
 
-	if herr.Code() != 500 {
+	if herr.Code() != http.StatusBadGateway {
 		t.Error("1: unexpected code")
 	}
 
@@ -54,7 +54,7 @@ func TestHTTPErrorFromResponse(t *testing.T) {
 		t.Error("2: unexpected error")
 	}
 
-	if herr.Code() != 400 {
+	if herr.Code() != http.StatusNotFound {
 		t.Error("2: unexpected code")
 	}
 }
@@ -3,28 +3,91 @@ package client
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 )
 
 // HTTP holds pre-configured http.Client.
 type HTTP struct {
 	c       *http.Client
+	lc      *http.Client // for login requests, does not follow redirects
+	login   *Login
+	auth    *authorizer
+	oauth   *tokenSource
+	scope   *authScope
+	check   bool              // detect session loss, without login recipe
+	session map[string]string // headers, captured on login
 	ua      string
 	cookies []*http.Cookie
 	headers []*header
+	authGen atomic.Uint64
+	authMu  sync.Mutex
+	sessMu  sync.RWMutex
 }
 
 // New creates and configure client for later use.
 func New(cfg *Config) (h *HTTP) {
-	transport := &http.Transport{
+	transport := newTransport(cfg)
+
+	rt := &limitTransport{
+		next:  transport,
+		limit: newHostLimiter(cfg.HostRate, cfg.HostConns),
+	}
+
+	client := &http.Client{
+		Timeout:       cfg.Timeout,
+		Transport:     rt,
+		CheckRedirect: checkRedirect(redirectLimit(cfg.MaxRedirects)),
+	}
+
+	switch {
+	case cfg.Jar != nil:
+		client.Jar = cfg.Jar
+	case cfg.Login != nil:
+		// session cookies must be kept somewhere
+		client.Jar = NewJar()
+	}
+
+	h = &HTTP{
+		ua:      cfg.UserAgent,
+		c:       client,
+		login:   cfg.Login,
+		auth:    newAuthorizer(cfg.Auth),
+		oauth:   newTokenSource(cfg.OAuth, &http.Client{Timeout: cfg.Timeout, Transport: transport}),
+		scope:   newAuthScope(cfg.AuthHosts),
+		check:   cfg.SessionCheck,
+		headers: prepareHeaders(cfg.allHeaders()),
+		cookies: prepareCookies(cfg.Cookies),
+	}
+
+	if h.login != nil {
+		h.lc = &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: rt,
+			Jar:       client.Jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return h
+}
+
+func newTransport(cfg *Config) (transport *http.Transport) {
+	transport = &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		Dial: (&net.Dialer{
 			Timeout: cfg.Timeout,
 		}).Dial,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: cfg.SkipSSL,
+			RootCAs:            cfg.RootCAs,
 		},
 		IdleConnTimeout:     cfg.Timeout,
 		TLSHandshakeTimeout: cfg.Timeout,
@@ -33,70 +96,157 @@ func New(cfg *Config) (h *HTTP) {
 		MaxIdleConnsPerHost: cfg.Workers,
 	}
 
-	client := &http.Client{
-		Timeout:   cfg.Timeout,
-		Transport: transport,
+	if cfg.Cert != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*cfg.Cert}
 	}
 
-	return &HTTP{
-		ua:      cfg.UserAgent,
-		c:       client,
-		headers: prepareHeaders(cfg.Headers),
-		cookies: prepareCookies(cfg.Cookies),
+	if cfg.HostConns > 0 {
+		transport.MaxConnsPerHost = min(cfg.Workers, cfg.HostConns)
+	}
+
+	return transport
+}
+
+// Get sends http GET request, returns non-closed body (for error responses too) and response metadata,
+// which is never nil, or error.
+func (h *HTTP) Get(ctx context.Context, url string) (body io.ReadCloser, resp *Response, err error) {
+	return h.request(ctx, http.MethodGet, url)
+}
+
+// Head sends http HEAD request, returns response metadata, which is never nil, or error.
+func (h *HTTP) Head(ctx context.Context, url string) (resp *Response, err error) {
+	var body io.ReadCloser
+
+	if body, resp, err = h.request(ctx, http.MethodHead, url); body != nil {
+		Discard(body)
 	}
+
+	return resp, err
 }
 
-// Get sends http GET request, returns non-closed body or error.
-func (h *HTTP) Get(ctx context.Context, url string) (body io.ReadCloser, hdrs http.Header, err error) {
-	var req *http.Request
+// Discard read all contents from ReaderCloser, closing it afterwards.
+func Discard(rc io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, rc)
+	_ = rc.Close()
+}
 
-	if req, err = http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		url,
-		http.NoBody,
-	); err != nil {
-		return
+// request sends request, any non-2xx response is reported as HTTPError.
+func (h *HTTP) request(
+	ctx context.Context,
+	method, url string,
+) (body io.ReadCloser, resp *Response, err error) {
+	var hresp *http.Response
+
+	if hresp, err = h.do(ctx, method, url); err != nil {
+		return nil, &Response{}, err
 	}
 
-	if body, hdrs, err = h.request(req); err != nil {
-		return
+	if resp = newResponse(hresp); !resp.Success() {
+		err = ErrFromResp(hresp)
 	}
 
-	return body, hdrs, nil
+	return hresp.Body, resp, err
 }
 
-// Head sends http HEAD request, return response headers or error.
-func (h *HTTP) Head(ctx context.Context, url string) (hdrs http.Header, err error) {
+// do sends request, if it was redirected to login page - re-authenticates and sends it once again,
+// ErrSessionLost is returned, if there is no login recipe, or it fails.
+func (h *HTTP) do(ctx context.Context, method, url string) (resp *http.Response, err error) {
+	gen := h.authGen.Load()
+
+	if resp, err = h.send(ctx, method, url); err != nil || !h.sessionLost(resp, url) {
+		return resp, err
+	}
+
+	Discard(resp.Body)
+
+	if h.login == nil {
+		return nil, fmt.Errorf("%w: redirected to: %s", ErrSessionLost, resp.Request.URL)
+	}
+
+	log.Printf("[!] session lost at: %s, logging in again", url)
+
+	if err = h.relogin(ctx, gen); err != nil {
+		return nil, fmt.Errorf("%w: relogin: %w", ErrSessionLost, err)
+	}
+
+	return h.send(ctx, method, url)
+}
+
+// send sends request, answering digest challenge (if any), or refreshing rejected oauth token.
+func (h *HTTP) send(ctx context.Context, method, url string) (resp *http.Response, err error) {
+	if resp, err = h.sendOnce(ctx, method, url); err != nil {
+		return resp, err
+	}
+
+	if !h.scope.Allow(resp.Request.URL) || (!h.auth.Challenge(resp) && !h.oauth.Reject(resp)) {
+		return resp, nil
+	}
+
+	Discard(resp.Body)
+
+	return h.sendOnce(ctx, method, url)
+}
+
+func (h *HTTP) sendOnce(ctx context.Context, method, url string) (resp *http.Response, err error) {
 	var req *http.Request
 
 	if req, err = http.NewRequestWithContext(
 		ctx,
-		http.MethodHead,
+		method,
 		url,
 		http.NoBody,
 	); err != nil {
 		return
 	}
 
-	var body io.ReadCloser
+	h.prepare(req)
 
-	if body, hdrs, err = h.request(req); err != nil {
-		return
+	h.sessMu.RLock()
+	for k, v := range h.session {
+		req.Header.Set(k, v)
+	}
+	h.sessMu.RUnlock()
+
+	if !h.scope.Allow(req.URL) {
+		return h.c.Do(req)
 	}
 
-	Discard(body)
+	h.auth.Authorize(req)
 
-	return hdrs, nil
+	if err = h.oauth.Authorize(req); err != nil {
+		return nil, err
+	}
+
+	return h.c.Do(req)
 }
 
-// Discard read all contents from ReaderCloser, closing it afterwards.
-func Discard(rc io.ReadCloser) {
-	_, _ = io.Copy(io.Discard, rc)
-	_ = rc.Close()
+func (h *HTTP) sessionLost(resp *http.Response, url string) (yes bool) {
+	if resp.Request == nil {
+		return false
+	}
+
+	final := resp.Request.URL
+
+	// redirect could be stopped by policy, so check where it leads
+	if loc, err := resp.Location(); err == nil && isRedirect(resp.StatusCode) {
+		final = loc
+	}
+
+	if final.String() == url {
+		return false
+	}
+
+	switch {
+	case h.login != nil:
+		return h.login.Page(final)
+	case h.check:
+		return isLoginPage(final)
+	}
+
+	return false
 }
 
-func (h *HTTP) request(req *http.Request) (body io.ReadCloser, hdrs http.Header, err error) {
+func (h *HTTP) prepare(req *http.Request) {
 	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.8")
 	req.Header.Set("Cache-Control", "no-cache")
@@ -104,18 +254,6 @@ func (h *HTTP) request(req *http.Request) (body io.ReadCloser, hdrs http.Header,
 	req.Header.Set("Referer", req.URL.String())
 
 	h.enrich(req)
-
-	var resp *http.Response
-
-	if resp, err = h.c.Do(req); err != nil {
-		return
-	}
-
-	if http.StatusOK < resp.StatusCode && resp.StatusCode >= http.StatusMultipleChoices {
-		err = ErrFromResp(resp)
-	}
-
-	return resp.Body, resp.Header, err
 }
 
 func (h *HTTP) enrich(req *http.Request) {
@@ -131,14 +131,18 @@ func TestHTTPHeadOK(t *testing.T) {
 
 	defer ts.Close()
 
-	hdr, err := c.Head(t.Context(), ts.URL)
+	resp, err := c.Head(t.Context(), ts.URL)
 	if err != nil {
 		t.Fatal("head:", err)
 	}
 
-	if hdr.Get(key) != val {
+	if resp.Header.Get(key) != val {
 		t.Error("bad key")
 	}
+
+	if resp.Code != http.StatusNoContent || resp.Reason != "No Content" || resp.URL.String() != ts.URL {
+		t.Errorf("unexpected response: %+v", resp)
+	}
 }
 
 func TestHTTPHeadERR(t *testing.T) {
@@ -152,7 +156,7 @@ func TestHTTPHeadERR(t *testing.T) {
 
 	defer ts.Close()
 
-	if _, err := c.Head(t.Context(), "]"); err == nil {
+	if resp, err := c.Head(t.Context(), "]"); err == nil || resp == nil || resp.Code != 0 {
 		t.Error("url - err is nil")
 	}
 
@@ -162,7 +166,12 @@ func TestHTTPHeadERR(t *testing.T) {
 		t.Error("ctx - err is nil")
 	}
 
-	if _, err := c.Head(t.Context(), ts.URL); err == nil {
+	resp, err := c.Head(t.Context(), ts.URL)
+	if err == nil {
 		t.Error("status - err is nil")
 	}
+
+	if resp.Code != http.StatusInternalServerError || resp.Reason != "Internal Server Error" || resp.Success() {
+		t.Errorf("unexpected response: %+v", resp)
+	}
 }
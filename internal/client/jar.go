@@ -0,0 +1,330 @@
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	netscapeFields   = 7
+	netscapeTrue     = "TRUE"
+	netscapeFalse    = "FALSE"
+	netscapeHTTPOnly = "#HttpOnly_"
+	netscapeHeader   = "# Netscape HTTP Cookie File"
+)
+
+// ErrBadCookieLine is returned, when line in cookies file cannot be parsed.
+var ErrBadCookieLine = errors.New("bad cookie line")
+
+// jarEntry is a single cookie, as it is stored in Netscape cookies.txt file.
+type jarEntry struct {
+	Expires  time.Time
+	Domain   string
+	Path     string
+	Name     string
+	Value    string
+	Wildcard bool // cookie is valid for subdomains
+	Secure   bool
+	HTTPOnly bool
+}
+
+func (e *jarEntry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *jarEntry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+// Jar is a thread-safe http.CookieJar, it remembers all cookies set, so they can be
+// exported to (and imported from) Netscape cookies.txt format.
+type Jar struct {
+	jar     *cookiejar.Jar
+	entries map[string]*jarEntry
+	mu      sync.Mutex
+}
+
+// NewJar creates empty cookie jar.
+func NewJar() (j *Jar) {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+
+	return &Jar{
+		jar:     jar,
+		entries: make(map[string]*jarEntry),
+	}
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := strings.ToLower(u.Hostname())
+
+	for _, c := range cookies {
+		e := newJarEntry(u, c, now)
+
+		if e.Wildcard && !domainMatch(host, e.Domain[1:]) {
+			// rejected by underlying jar
+			continue
+		}
+
+		if e.expired(now) {
+			delete(j.entries, e.key())
+
+			continue
+		}
+
+		j.entries[e.key()] = e
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Len returns count of stored cookies.
+func (j *Jar) Len() (n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return len(j.entries)
+}
+
+// Load imports cookies from Netscape cookies.txt format, expired cookies are skipped.
+func (j *Jar) Load(r io.Reader) (err error) {
+	var (
+		sc  = bufio.NewScanner(r)
+		now = time.Now()
+		num int
+	)
+
+	for sc.Scan() {
+		num++
+
+		e, ok, err := parseNetscape(sc.Text())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", num, err)
+		}
+
+		if !ok || e.expired(now) {
+			continue
+		}
+
+		scheme := "http"
+		if e.Secure {
+			scheme = "https"
+		}
+
+		c := &http.Cookie{
+			Name:     e.Name,
+			Value:    e.Value,
+			Path:     e.Path,
+			Expires:  e.Expires,
+			Secure:   e.Secure,
+			HttpOnly: e.HTTPOnly,
+		}
+
+		if e.Wildcard {
+			c.Domain = e.Domain
+		}
+
+		j.SetCookies(&url.URL{
+			Scheme: scheme,
+			Host:   strings.TrimPrefix(e.Domain, "."),
+			Path:   e.Path,
+		}, []*http.Cookie{c})
+	}
+
+	if err = sc.Err(); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	return nil
+}
+
+// Save exports all non-expired cookies in Netscape cookies.txt format.
+func (j *Jar) Save(w io.Writer) (err error) {
+	now := time.Now()
+
+	j.mu.Lock()
+
+	entries := make([]*jarEntry, 0, len(j.entries))
+
+	for _, e := range j.entries {
+		if !e.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+
+	j.mu.Unlock()
+
+	slices.SortFunc(entries, func(a, b *jarEntry) int {
+		return strings.Compare(a.key(), b.key())
+	})
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, netscapeHeader)
+	fmt.Fprintln(bw)
+
+	for _, e := range entries {
+		fmt.Fprintln(bw, formatNetscape(e))
+	}
+
+	if err = bw.Flush(); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func newJarEntry(u *url.URL, c *http.Cookie, now time.Time) (e *jarEntry) {
+	e = &jarEntry{
+		Domain:   strings.ToLower(u.Hostname()),
+		Path:     c.Path,
+		Name:     c.Name,
+		Value:    c.Value,
+		Secure:   c.Secure,
+		HTTPOnly: c.HttpOnly,
+	}
+
+	if d := strings.TrimPrefix(strings.ToLower(c.Domain), "."); d != "" {
+		e.Domain, e.Wildcard = "."+d, true
+	}
+
+	if e.Path == "" || e.Path[0] != '/' {
+		e.Path = defaultCookiePath(u.Path)
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		e.Expires = now
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		e.Expires = c.Expires
+	}
+
+	return e
+}
+
+func domainMatch(host, domain string) (yes bool) {
+	if host == domain {
+		return true
+	}
+
+	if ps, _ := publicsuffix.PublicSuffix(domain); ps == domain {
+		return false
+	}
+
+	return strings.HasSuffix(host, "."+domain)
+}
+
+// defaultCookiePath returns default path for cookie, as RFC 6265 (section 5.1.4) defines it.
+func defaultCookiePath(p string) (rv string) {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+
+	if rv = path.Dir(p); rv == "." {
+		return "/"
+	}
+
+	return rv
+}
+
+func parseNetscape(line string) (e *jarEntry, ok bool, err error) {
+	var httpOnly bool
+
+	if line, httpOnly = strings.CutPrefix(line, netscapeHTTPOnly); !httpOnly {
+		if line = strings.TrimSpace(line); line == "" || line[0] == '#' {
+			return nil, false, nil
+		}
+	}
+
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	if len(fields) != netscapeFields {
+		return nil, false, fmt.Errorf("%w: expected %d fields, got %d", ErrBadCookieLine, netscapeFields, len(fields))
+	}
+
+	e = &jarEntry{
+		Domain:   strings.ToLower(fields[0]),
+		Wildcard: strings.EqualFold(fields[1], netscapeTrue),
+		Path:     fields[2],
+		Secure:   strings.EqualFold(fields[3], netscapeTrue),
+		Name:     fields[5],
+		Value:    fields[6],
+		HTTPOnly: httpOnly,
+	}
+
+	if e.Domain == "" || e.Name == "" {
+		return nil, false, fmt.Errorf("%w: empty domain or name", ErrBadCookieLine)
+	}
+
+	exp, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: expires: %w", ErrBadCookieLine, err)
+	}
+
+	if exp > 0 {
+		e.Expires = time.Unix(exp, 0)
+	}
+
+	if e.Wildcard && e.Domain[0] != '.' {
+		e.Domain = "." + e.Domain
+	}
+
+	return e, true, nil
+}
+
+func formatNetscape(e *jarEntry) (rv string) {
+	var (
+		domain = e.Domain
+		exp    int64
+	)
+
+	if e.HTTPOnly {
+		domain = netscapeHTTPOnly + domain
+	}
+
+	if !e.Expires.IsZero() {
+		exp = e.Expires.Unix()
+	}
+
+	return strings.Join([]string{
+		domain,
+		netscapeBool(e.Wildcard),
+		e.Path,
+		netscapeBool(e.Secure),
+		strconv.FormatInt(exp, 10),
+		e.Name,
+		e.Value,
+	}, "\t")
+}
+
+func netscapeBool(v bool) (rv string) {
+	if v {
+		return netscapeTrue
+	}
+
+	return netscapeFalse
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCookies = `# Netscape HTTP Cookie File
+# comment
+
+.example.com	TRUE	/	FALSE	0	session	one
+#HttpOnly_www.example.com	FALSE	/app	TRUE	4102444800	token	two
+old.example.com	FALSE	/	FALSE	1	expired	three
+`
+
+func TestJarLoadSave(t *testing.T) {
+	t.Parallel()
+
+	j := NewJar()
+
+	if err := j.Load(strings.NewReader(testCookies)); err != nil {
+		t.Fatal("load:", err)
+	}
+
+	if j.Len() != 2 {
+		t.Fatal("unexpected len:", j.Len())
+	}
+
+	u, _ := url.Parse("https://www.example.com/app/page")
+
+	if n := len(j.Cookies(u)); n != 2 {
+		t.Error("https - unexpected cookies count:", n)
+	}
+
+	u, _ = url.Parse("http://api.example.com/")
+
+	if c := j.Cookies(u); len(c) != 1 || c[0].Name != "session" {
+		t.Error("subdomain - unexpected cookies:", c)
+	}
+
+	var buf bytes.Buffer
+
+	if err := j.Save(&buf); err != nil {
+		t.Fatal("save:", err)
+	}
+
+	out := buf.String()
+
+	if !strings.HasPrefix(out, netscapeHeader) {
+		t.Error("no header")
+	}
+
+	if !strings.Contains(out, ".example.com\tTRUE\t/\tFALSE\t0\tsession\tone\n") {
+		t.Error("no session cookie:", out)
+	}
+
+	if !strings.Contains(out, "#HttpOnly_www.example.com\tFALSE\t/app\tTRUE\t4102444800\ttoken\ttwo\n") {
+		t.Error("no http-only cookie:", out)
+	}
+
+	if strings.Contains(out, "expired") {
+		t.Error("expired cookie saved")
+	}
+
+	j2 := NewJar()
+
+	if err := j2.Load(&buf); err != nil || j2.Len() != 2 {
+		t.Error("reload:", err, j2.Len())
+	}
+}
+
+func TestJarLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{
+		"example.com\tTRUE\t/\n",
+		"example.com\tTRUE\t/\tFALSE\tnever\tname\tvalue\n",
+		"\tTRUE\t/\tFALSE\t0\tname\tvalue\n",
+	} {
+		if err := NewJar().Load(strings.NewReader(s)); !errors.Is(err, ErrBadCookieLine) {
+			t.Errorf("%q - unexpected error: %v", s, err)
+		}
+	}
+}
+
+func TestJarSetCookies(t *testing.T) {
+	t.Parallel()
+
+	j := NewJar()
+	u, _ := url.Parse("http://www.example.com/a/b")
+
+	j.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: "example.com", MaxAge: 60},
+		{Name: "alien", Value: "3", Domain: "other.com"},
+		{Name: "suffix", Value: "4", Domain: "com"},
+	})
+
+	if j.Len() != 2 {
+		t.Fatal("unexpected len:", j.Len())
+	}
+
+	j.SetCookies(u, []*http.Cookie{{Name: "host", MaxAge: -1}})
+
+	if j.Len() != 1 {
+		t.Error("cookie not deleted")
+	}
+
+	var buf bytes.Buffer
+
+	_ = j.Save(&buf)
+
+	if !strings.Contains(buf.String(), ".example.com\tTRUE\t/a\tFALSE\t") {
+		t.Error("unexpected output:", buf.String())
+	}
+
+	j.SetCookies(u, []*http.Cookie{{Name: "gone", Expires: time.Unix(1, 0)}})
+
+	if j.Len() != 1 {
+		t.Error("expired cookie stored")
+	}
+}
+
+func TestHTTPJar(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "secret", Path: "/"})
+
+			return
+		}
+
+		if c, err := r.Cookie("sid"); err != nil || c.Value != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	defer ts.Close()
+
+	tc := cfg
+	tc.Jar = NewJar()
+
+	c := New(&tc)
+
+	body, _, err := c.Get(t.Context(), ts.URL+"/login")
+	if err != nil {
+		t.Fatal("login:", err)
+	}
+
+	Discard(body)
+
+	if body, _, err = c.Get(t.Context(), ts.URL+"/private"); err != nil {
+		t.Fatal("private:", err)
+	}
+
+	_, _ = io.Copy(io.Discard, body)
+	Discard(body)
+
+	if tc.Jar.Len() != 1 {
+		t.Error("unexpected jar len:", tc.Jar.Len())
+	}
+}
+
+func TestDefaultCookiePath(t *testing.T) {
+	t.Parallel()
+
+	for have, want := range map[string]string{
+		"":       "/",
+		"x":      "/",
+		"/":      "/",
+		"/a":     "/",
+		"/a/b":   "/a",
+		"/a/b/c": "/a/b",
+	} {
+		if got := defaultCookiePath(have); got != want {
+			t.Errorf("%q: want: %q got: %q", have, want, got)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	throttleFloor   = 250 * time.Millisecond // interval, throttling starts from, for unlimited hosts
+	throttleMax     = time.Minute            // max interval between requests
+	throttleReset   = 10 * time.Millisecond  // throttling stops, once interval gets below it
+	throttleStep    = 0.5                    // requests per second, added on every success
+	maxRetryAfter   = 5 * time.Minute
+	throttleBackoff = 2
+)
+
+// hostLimiter limits request rate (token bucket, with burst of single request) and concurrent
+// requests count for every host. Rate is adapted (AIMD-style), if host answers with 429 or 503:
+// interval between requests is doubled (and Retry-After is respected), then it is decreased
+// gradually, with every successful response, back to configured one.
+type hostLimiter struct {
+	hosts map[string]*hostLimit
+	every time.Duration // interval between requests, 0 - unlimited
+	conns int           // max concurrent requests, 0 - unlimited
+	mu    sync.Mutex
+}
+
+type hostLimit struct {
+	sem   chan struct{}
+	next  time.Time     // when next request can be sent
+	calm  time.Time     // interval is not increased again until it, so burst of 429 / 503 slows host once
+	every time.Duration // current interval between requests
+	mu    sync.Mutex
+}
+
+func newHostLimiter(rate float64, conns int) (rv *hostLimiter) {
+	rv = &hostLimiter{
+		hosts: make(map[string]*hostLimit),
+		conns: max(0, conns),
+	}
+
+	if rate > 0 {
+		rv.every = time.Duration(float64(time.Second) / rate)
+	}
+
+	return rv
+}
+
+func (l *hostLimiter) get(host string) (hl *hostLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	host = strings.ToLower(host)
+
+	if hl = l.hosts[host]; hl == nil {
+		hl = &hostLimit{every: l.every}
+
+		if l.conns > 0 {
+			hl.sem = make(chan struct{}, l.conns)
+		}
+
+		l.hosts[host] = hl
+	}
+
+	return hl
+}
+
+// Acquire waits, until request to host can be sent, release must be called, once it is done.
+func (l *hostLimiter) Acquire(ctx context.Context, host string) (release func(), err error) {
+	hl := l.get(host)
+	release = func() {}
+
+	if hl.sem != nil {
+		select {
+		case hl.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		release = sync.OnceFunc(func() { <-hl.sem })
+	}
+
+	if wait := hl.reserve(); wait > 0 {
+		if err = sleepContext(ctx, wait); err != nil {
+			release()
+
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// Feedback adapts host rate by response status.
+func (l *hostLimiter) Feedback(host string, resp *http.Response) {
+	hl := l.get(host)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		wait := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		every := hl.throttle(wait)
+
+		log.Printf("[!] throttled by %s (%s), interval: %s, retry after: %s", host, resp.Status, every, wait)
+	default:
+		if resp.StatusCode < http.StatusBadRequest {
+			hl.recover(l.every)
+		}
+	}
+}
+
+// reserve takes single token, returns time to wait for it.
+func (hl *hostLimit) reserve() (wait time.Duration) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	now := time.Now()
+
+	if hl.next.Before(now) {
+		hl.next = now
+	}
+
+	wait = hl.next.Sub(now)
+	hl.next = hl.next.Add(hl.every)
+
+	return wait
+}
+
+// throttle multiplicatively increases interval, and postpones next request for it, or for wait, if it is longer.
+// Further slowdowns are ignored (only wait is respected), until postponed request is due.
+func (hl *hostLimit) throttle(wait time.Duration) (every time.Duration) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	now := time.Now()
+
+	if !now.Before(hl.calm) {
+		hl.every = min(throttleMax, max(hl.every*throttleBackoff, throttleFloor))
+		wait = max(wait, hl.every)
+		hl.calm = now.Add(wait)
+	}
+
+	if next := now.Add(wait); next.After(hl.next) {
+		hl.next = next
+	}
+
+	return hl.every
+}
+
+// recover additively increases rate, until base interval is reached.
+func (hl *hostLimit) recover(base time.Duration) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	if hl.every <= base {
+		return
+	}
+
+	rps := float64(time.Second)/float64(hl.every) + throttleStep
+	hl.every = time.Duration(float64(time.Second) / rps)
+
+	if hl.every <= max(base, throttleReset) {
+		hl.every = base
+	}
+}
+
+// parseRetryAfter parses Retry-After header value, it can hold seconds or http date.
+func parseRetryAfter(v string, now time.Time) (d time.Duration) {
+	if v = strings.TrimSpace(v); v == "" {
+		return 0
+	}
+
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		d = time.Duration(n) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+
+	return min(maxRetryAfter, max(0, d))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) (err error) {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitTransport applies host limits to every request, including redirects, host slot is
+// released, once response body is closed.
+type limitTransport struct {
+	next  http.RoundTripper
+	limit *hostLimiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (lt *limitTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	release, err := lt.limit.Acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp, err = lt.next.RoundTrip(req); err != nil {
+		release()
+
+		return nil, err
+	}
+
+	lt.limit.Feedback(req.URL.Host, resp)
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+
+	release func()
+}
+
+func (rb *releaseBody) Close() (err error) {
+	err = rb.ReadCloser.Close()
+	rb.release()
+
+	return err
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHostLimiter(t *testing.T) {
+	t.Parallel()
+
+	if l := newHostLimiter(0, -1); l.every != 0 || l.conns != 0 {
+		t.Error("unlimited - unexpected limiter")
+	}
+
+	if l := newHostLimiter(-1, 2); l.every != 0 || l.conns != 2 {
+		t.Error("unexpected limiter")
+	}
+}
+
+func TestHostLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	l := newHostLimiter(100, 0)
+
+	var waits [2]time.Duration
+
+	for range 3 {
+		waits[0] = l.get("a").reserve()
+		waits[1] = l.get("B").reserve()
+	}
+
+	// hosts are independent, so both have waited for 2 previous requests
+	for i, w := range waits {
+		if w < 15*time.Millisecond || w > 20*time.Millisecond {
+			t.Errorf("%d: unexpected wait: %s", i, w)
+		}
+	}
+
+	if l.get("b") != l.get("B") {
+		t.Error("host case matters")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := l.Acquire(ctx, "a"); err == nil {
+		t.Error("canceled - no error")
+	}
+}
+
+func TestHostLimiterConns(t *testing.T) {
+	t.Parallel()
+
+	l := newHostLimiter(0, 1)
+
+	release, err := l.Acquire(t.Context(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err = l.Acquire(ctx, "a"); err == nil {
+		t.Error("busy - no error")
+	}
+
+	if _, err = l.Acquire(t.Context(), "b"); err != nil {
+		t.Error("other host - unexpected error:", err)
+	}
+
+	release()
+	release() // must be safe
+
+	if _, err = l.Acquire(t.Context(), "a"); err != nil {
+		t.Error("released - unexpected error:", err)
+	}
+}
+
+func TestHTTPHostLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cur, peak atomic.Int32
+		hits      atomic.Int32
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		n := cur.Add(1)
+		defer cur.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		hits.Add(1)
+		time.Sleep(10 * time.Millisecond)
+	}))
+
+	defer ts.Close()
+
+	const (
+		workers  = 8
+		requests = 6
+		rate     = 200
+	)
+
+	tc := cfg
+	tc.Workers = workers
+	tc.HostConns = 2
+	tc.HostRate = rate
+
+	c := New(&tc)
+
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for range requests {
+		wg.Go(func() {
+			body, _, err := c.Get(t.Context(), ts.URL)
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			Discard(body)
+		})
+	}
+
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Error("unexpected concurrency:", p)
+	}
+
+	if hits.Load() != requests {
+		t.Error("unexpected hits:", hits.Load())
+	}
+
+	// 6 requests, 2 at time, 10ms each
+	if d := time.Since(start); d < 30*time.Millisecond {
+		t.Error("too fast:", d)
+	}
+}
+
+func TestHostLimiterThrottle(t *testing.T) {
+	t.Parallel()
+
+	const base = 500 * time.Millisecond
+
+	l := newHostLimiter(2, 0)
+	hl := l.get("a")
+
+	if every := hl.throttle(time.Second); every != 2*base {
+		t.Error("unexpected interval:", every)
+	}
+
+	if wait := hl.reserve(); wait < 900*time.Millisecond || wait > time.Second {
+		t.Error("retry-after is not respected:", wait)
+	}
+
+	// burst of slowdowns throttles host once
+	for range 10 {
+		hl.throttle(0)
+	}
+
+	if hl.every != 2*base {
+		t.Error("unexpected interval after burst:", hl.every)
+	}
+
+	for range 10 {
+		hl.calm = time.Time{}
+		hl.throttle(0)
+	}
+
+	if hl.every != throttleMax {
+		t.Error("unexpected max interval:", hl.every)
+	}
+
+	var steps int
+
+	for hl.every > base {
+		hl.recover(base)
+		steps++
+	}
+
+	// rate grows by 0.5 rps, from 1/60 to 2 rps
+	if hl.every != base || steps != 4 {
+		t.Error("unexpected recover:", hl.every, steps)
+	}
+
+	// unlimited host
+	hl = newHostLimiter(0, 0).get("b")
+
+	if every := hl.throttle(0); every != throttleFloor {
+		t.Error("unlimited - unexpected interval:", every)
+	}
+
+	for hl.every > 0 {
+		hl.recover(0)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for have, want := range map[string]time.Duration{
+		"":                              0,
+		"bad":                           0,
+		"-5":                            0,
+		" 3 ":                           3 * time.Second,
+		"86400":                         maxRetryAfter,
+		"Thu, 01 Jan 2026 00:00:30 GMT": 30 * time.Second,
+		"Wed, 31 Dec 2025 23:00:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(have, now); got != want {
+			t.Errorf("%q: want: %s got: %s", have, want, got)
+		}
+	}
+}
+
+func TestHTTPThrottle(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+
+	defer ts.Close()
+
+	tc := cfg
+	c := New(&tc)
+
+	_, err := c.Head(t.Context(), ts.URL)
+
+	var herr HTTPError
+
+	if !errors.As(err, &herr) || herr.Code() != http.StatusTooManyRequests {
+		t.Fatal("unexpected error:", err)
+	}
+
+	start := time.Now()
+
+	if _, err = c.Head(t.Context(), ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := time.Since(start); d < throttleFloor-10*time.Millisecond {
+		t.Error("not throttled:", d)
+	}
+}
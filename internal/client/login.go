@@ -0,0 +1,244 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const contentForm = "application/x-www-form-urlencoded"
+
+// loginPage matches common login pages paths, it is used to detect session loss, when no recipe is given.
+var loginPage = regexp.MustCompile(`(?i)(^|/)(log[-_]?in|sign[-_]?in|auth|sso)(/|\.|$)`)
+
+var (
+	// ErrSessionLost is returned, when response was redirected to login page, and session cannot be restored.
+	ErrSessionLost = errors.New("session lost")
+	// ErrLoginStep is returned, when login step response does not match expectations.
+	ErrLoginStep = errors.New("unexpected login response")
+	// ErrLoginRecipe is returned for invalid login recipes.
+	ErrLoginRecipe = errors.New("bad login recipe")
+)
+
+// LoginExpect describes expected response for login step.
+type LoginExpect struct {
+	// Redirect is an url (or path), response should redirect to.
+	Redirect string `json:"redirect,omitempty"`
+	// Status is an expected response status code.
+	Status int `json:"status,omitempty"`
+}
+
+// LoginStep is a single request of login recipe.
+type LoginStep struct {
+	// Form holds form fields, sent url-encoded.
+	Form map[string]string `json:"form,omitempty"`
+	// Headers holds extra request headers for this step.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Method is a request method, POST if form is given, GET otherwise.
+	Method string `json:"method,omitempty"`
+	// URL is a request url.
+	URL string `json:"url"`
+	// Capture lists response headers, to be sent with every following request.
+	Capture []string `json:"capture,omitempty"`
+	// Expect is an expected response, any non-error status is accepted, if empty.
+	Expect LoginExpect `json:"expect"`
+}
+
+// Login is a recipe of requests, that should be made to obtain session.
+type Login struct {
+	// URL is a login page, redirect to it means session loss, url of first step is used, if empty.
+	URL   string      `json:"login_url,omitempty"`
+	Steps []LoginStep `json:"steps"`
+}
+
+// LoadLogin reads and validates json login recipe.
+func LoadLogin(r io.Reader) (l *Login, err error) {
+	l = &Login{}
+
+	if err = json.NewDecoder(r).Decode(l); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(l.Steps) == 0 {
+		return nil, fmt.Errorf("%w: no steps", ErrLoginRecipe)
+	}
+
+	for i := range l.Steps {
+		s := &l.Steps[i]
+
+		if _, err = url.Parse(s.URL); err != nil || s.URL == "" {
+			return nil, fmt.Errorf("%w: step %d: bad url: %q", ErrLoginRecipe, i+1, s.URL)
+		}
+
+		if s.Method == "" {
+			s.Method = http.MethodGet
+
+			if len(s.Form) > 0 {
+				s.Method = http.MethodPost
+			}
+		}
+
+		s.Method = strings.ToUpper(s.Method)
+	}
+
+	if l.URL == "" {
+		l.URL = l.Steps[0].URL
+	}
+
+	if _, err = url.Parse(l.URL); err != nil {
+		return nil, fmt.Errorf("%w: bad login url: %w", ErrLoginRecipe, err)
+	}
+
+	return l, nil
+}
+
+// Page reports, if u points to login page.
+func (l *Login) Page(u *url.URL) (yes bool) {
+	lu, err := url.Parse(l.URL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(lu.Host, u.Host) && strings.TrimSuffix(lu.Path, "/") == strings.TrimSuffix(u.Path, "/")
+}
+
+// isLoginPage reports, if u looks like login page.
+func isLoginPage(u *url.URL) (yes bool) {
+	return loginPage.MatchString(u.Path)
+}
+
+// Login obtains oauth token and runs login recipe (if any), keeping resulting cookies and captured headers for crawl.
+func (h *HTTP) Login(ctx context.Context) (err error) {
+	if h.oauth != nil {
+		if _, err = h.oauth.Token(ctx); err != nil {
+			return fmt.Errorf("oauth: %w", err)
+		}
+	}
+
+	if h.login == nil {
+		return nil
+	}
+
+	h.authMu.Lock()
+	defer h.authMu.Unlock()
+
+	return h.runLogin(ctx)
+}
+
+// relogin runs login recipe again, unless it was already done by someone else, since gen.
+func (h *HTTP) relogin(ctx context.Context, gen uint64) (err error) {
+	h.authMu.Lock()
+	defer h.authMu.Unlock()
+
+	if h.authGen.Load() != gen {
+		return nil
+	}
+
+	return h.runLogin(ctx)
+}
+
+func (h *HTTP) runLogin(ctx context.Context) (err error) {
+	captured := make(map[string]string)
+
+	for i := range h.login.Steps {
+		if err = h.loginStep(ctx, &h.login.Steps[i], captured); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	h.sessMu.Lock()
+	h.session = captured
+	h.sessMu.Unlock()
+
+	h.authGen.Add(1)
+
+	return nil
+}
+
+func (h *HTTP) loginStep(ctx context.Context, s *LoginStep, captured map[string]string) (err error) {
+	var body io.Reader = http.NoBody
+
+	if len(s.Form) > 0 {
+		form := make(url.Values, len(s.Form))
+
+		for k, v := range s.Form {
+			form.Set(k, v)
+		}
+
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, s.Method, s.URL, body)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	h.prepare(req)
+
+	if len(s.Form) > 0 {
+		req.Header.Set("Content-Type", contentForm)
+	}
+
+	for k, v := range captured {
+		req.Header.Set(k, v)
+	}
+
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.lc.Do(req)
+	if err != nil {
+		return fmt.Errorf("do: %w", err)
+	}
+
+	Discard(resp.Body)
+
+	if err = s.Expect.check(resp); err != nil {
+		return err
+	}
+
+	for _, k := range s.Capture {
+		v := resp.Header.Get(k)
+		if v == "" {
+			return fmt.Errorf("%w: no %s header", ErrLoginStep, k)
+		}
+
+		captured[k] = v
+	}
+
+	return nil
+}
+
+func (e *LoginExpect) check(resp *http.Response) (err error) {
+	switch {
+	case e.Status != 0 && resp.StatusCode != e.Status:
+		return fmt.Errorf("%w: status %d, want %d", ErrLoginStep, resp.StatusCode, e.Status)
+	case e.Status == 0 && e.Redirect == "" && resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%w: status %d", ErrLoginStep, resp.StatusCode)
+	case e.Redirect == "":
+		return nil
+	}
+
+	loc, err := resp.Location()
+	if err != nil {
+		return fmt.Errorf("%w: no redirect", ErrLoginStep)
+	}
+
+	want, err := resp.Request.URL.Parse(e.Redirect)
+	if err != nil {
+		return fmt.Errorf("%w: bad redirect: %w", ErrLoginStep, err)
+	}
+
+	if loc.String() != want.String() {
+		return fmt.Errorf("%w: redirect to %s, want %s", ErrLoginStep, loc, want)
+	}
+
+	return nil
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testRecipe = `{
+	"steps": [
+		{"url": "%[1]s/login"},
+		{
+			"url": "%[1]s/login",
+			"form": {"user": "admin", "pass": "secret"},
+			"capture": ["X-Token"],
+			"expect": {"status": 302, "redirect": "/home"}
+		}
+	]
+}`
+
+type loginServer struct {
+	logins  atomic.Int32
+	session atomic.Int32
+}
+
+func (ls *loginServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/login":
+		if r.Method != http.MethodPost {
+			return
+		}
+
+		if r.FormValue("user") != "admin" || r.FormValue("pass") != "secret" ||
+			r.Header.Get("Content-Type") != contentForm {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		sid := ls.logins.Add(1)
+		ls.session.Store(sid)
+
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: fmt.Sprint(sid), Path: "/"})
+		w.Header().Set("X-Token", "token")
+		http.Redirect(w, r, "/home", http.StatusFound)
+	default:
+		c, err := r.Cookie("sid")
+		if err != nil || c.Value != fmt.Sprint(ls.session.Load()) || r.Header.Get("X-Token") != "token" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, "ok")
+	}
+}
+
+func TestLoadLogin(t *testing.T) {
+	t.Parallel()
+
+	l, err := LoadLogin(strings.NewReader(fmt.Sprintf(testRecipe, "http://test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.URL != "http://test/login" {
+		t.Error("unexpected login url:", l.URL)
+	}
+
+	if l.Steps[0].Method != http.MethodGet || l.Steps[1].Method != http.MethodPost {
+		t.Error("unexpected methods")
+	}
+
+	for _, s := range []string{
+		"{",
+		`{"steps": []}`,
+		`{"steps": [{"url": ""}]}`,
+		`{"steps": [{"url": "%"}]}`,
+		`{"login_url": "%", "steps": [{"url": "/"}]}`,
+	} {
+		if _, err := LoadLogin(strings.NewReader(s)); err == nil {
+			t.Errorf("%s - no error", s)
+		}
+	}
+}
+
+func TestLoginPage(t *testing.T) {
+	t.Parallel()
+
+	l := &Login{URL: "http://test/login/"}
+
+	for have, want := range map[string]bool{
+		"http://test/login":        true,
+		"http://TEST/login/?next=": true,
+		"http://test/logout":       false,
+		"http://other/login":       false,
+	} {
+		u, _ := url.Parse(have)
+
+		if got := l.Page(u); got != want {
+			t.Errorf("%s: want: %v got: %v", have, want, got)
+		}
+	}
+
+	if (&Login{URL: "%"}).Page(&url.URL{}) {
+		t.Error("bad url - login page")
+	}
+}
+
+func TestHTTPLogin(t *testing.T) {
+	t.Parallel()
+
+	ls := &loginServer{}
+	ts := httptest.NewServer(ls)
+
+	defer ts.Close()
+
+	l, err := LoadLogin(strings.NewReader(fmt.Sprintf(testRecipe, ts.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := cfg
+	tc.Login = l
+
+	c := New(&tc)
+
+	if err = c.Login(t.Context()); err != nil {
+		t.Fatal("login:", err)
+	}
+
+	get := func() string {
+		body, _, err := c.Get(t.Context(), ts.URL+"/page")
+		if err != nil {
+			t.Fatal("get:", err)
+		}
+
+		defer Discard(body)
+
+		buf, _ := io.ReadAll(body)
+
+		return string(buf)
+	}
+
+	if get() != "ok" {
+		t.Error("not logged in")
+	}
+
+	// server drops session
+	ls.session.Store(0)
+
+	if get() != "ok" {
+		t.Error("not logged in again")
+	}
+
+	if n := ls.logins.Load(); n != 2 {
+		t.Error("unexpected logins count:", n)
+	}
+}
+
+func TestHTTPLoginErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(&loginServer{})
+
+	defer ts.Close()
+
+	for _, recipe := range []string{
+		`{"steps": [{"url": "%[1]s/login", "form": {"user": "bad"}}]}`,
+		`{"steps": [{"url": "%[1]s/login", "form": {"user": "bad"}, "expect": {"status": 302}}]}`,
+		`{"steps": [{"url": "%[1]s/login", "expect": {"redirect": "/home"}}]}`,
+		`{"steps": [{"url": "%[1]s/page", "expect": {"redirect": "/home"}}]}`,
+		`{"steps": [{"url": "%[1]s/login", "capture": ["X-Token"]}]}`,
+		`{"steps": [{"url": "%[1]s/login", "method": "BAD METHOD"}]}`,
+		`{"steps": [{"url": "http://127.0.0.1:0/login"}]}`,
+	} {
+		l, err := LoadLogin(strings.NewReader(fmt.Sprintf(recipe, ts.URL)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc := cfg
+		tc.Login = l
+
+		if err = New(&tc).Login(t.Context()); err == nil {
+			t.Errorf("%s - no error", recipe)
+		}
+	}
+
+	if err := New(&cfg).Login(t.Context()); err != nil {
+		t.Error("no recipe - error:", err)
+	}
+}
+
+func TestHTTPReloginError(t *testing.T) {
+	t.Parallel()
+
+	ls := &loginServer{}
+	ts := httptest.NewServer(ls)
+
+	defer ts.Close()
+
+	tc := cfg
+	tc.Login = &Login{
+		URL:   ts.URL + "/login",
+		Steps: []LoginStep{{URL: ts.URL + "/login", Method: http.MethodPost, Form: map[string]string{"user": "bad"}}},
+	}
+
+	_, _, err := New(&tc).Get(t.Context(), ts.URL+"/page")
+	if !errors.Is(err, ErrLoginStep) || !errors.Is(err, ErrSessionLost) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestIsLoginPage(t *testing.T) {
+	t.Parallel()
+
+	for have, want := range map[string]bool{
+		"http://test/login":            true,
+		"http://test/users/sign_in":    true,
+		"http://test/auth/?next=/a":    true,
+		"http://test/signin.php":       true,
+		"http://test/blog/logins-list": false,
+		"http://test/author":           false,
+		"http://test/":                 false,
+	} {
+		u, _ := url.Parse(have)
+
+		if got := isLoginPage(u); got != want {
+			t.Errorf("%s: want: %v got: %v", have, want, got)
+		}
+	}
+}
+
+func TestHTTPSessionCheck(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page" {
+			http.Redirect(w, r, "/users/sign_in", http.StatusFound)
+		}
+	}))
+
+	defer ts.Close()
+
+	tc := cfg
+
+	if _, _, err := New(&tc).Get(t.Context(), ts.URL+"/page"); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	tc.SessionCheck = true
+
+	if _, _, err := New(&tc).Get(t.Context(), ts.URL+"/page"); !errors.Is(err, ErrSessionLost) {
+		t.Error("unexpected error:", err)
+	}
+
+	if _, _, err := New(&tc).Get(t.Context(), ts.URL+"/other"); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
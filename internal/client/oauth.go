@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	grantClientCredentials = "client_credentials"
+	tokenSkew              = 10 * time.Second
+	tokenTypeBearer        = "bearer"
+)
+
+// ErrToken is returned, when access token cannot be obtained.
+var ErrToken = errors.New("token request failed")
+
+// OAuth holds settings for OAuth2 client credentials grant (RFC 6749, section 4.4).
+type OAuth struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenSource keeps access token, fetching new one, when it expires (or rejected).
+type tokenSource struct {
+	cfg     *OAuth
+	c       *http.Client
+	expires time.Time // zero - never
+	token   string
+	mu      sync.Mutex
+}
+
+func newTokenSource(cfg *OAuth, c *http.Client) (rv *tokenSource) {
+	if cfg == nil {
+		return nil
+	}
+
+	return &tokenSource{cfg: cfg, c: c}
+}
+
+// Token returns valid access token, fetching new one, if needed.
+func (ts *tokenSource) Token(ctx context.Context) (token string, err error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && (ts.expires.IsZero() || time.Now().Before(ts.expires)) {
+		return ts.token, nil
+	}
+
+	if err = ts.fetch(ctx); err != nil {
+		return "", err
+	}
+
+	return ts.token, nil
+}
+
+// Authorize sets access token for request, it is nil-safe.
+func (ts *tokenSource) Authorize(req *http.Request) (err error) {
+	if ts == nil {
+		return nil
+	}
+
+	token, err := ts.Token(req.Context())
+	if err != nil {
+		return fmt.Errorf("oauth: %w", err)
+	}
+
+	req.Header.Set(headerAuthorize, schemeBearer+" "+token)
+
+	return nil
+}
+
+// Reject drops token, if it was rejected by server, returns true, if request should be sent again.
+func (ts *tokenSource) Reject(resp *http.Response) (yes bool) {
+	if ts == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	used := strings.TrimPrefix(resp.Request.Header.Get(headerAuthorize), schemeBearer+" ")
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	// token could be already refreshed by concurrent request
+	if ts.token == used {
+		ts.token = ""
+	}
+
+	return true
+}
+
+func (ts *tokenSource) fetch(ctx context.Context) (err error) {
+	form := url.Values{"grant_type": {grantClientCredentials}}
+
+	if len(ts.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentForm)
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(ts.cfg.ClientID), url.QueryEscape(ts.cfg.ClientSecret))
+
+	resp, err := ts.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("do: %w", err)
+	}
+
+	defer Discard(resp.Body)
+
+	var tr tokenResponse
+
+	if err = json.NewDecoder(resp.Body).Decode(&tr); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: %s %s", ErrToken, resp.Status, tr.Error)
+	case tr.AccessToken == "":
+		return fmt.Errorf("%w: no access token", ErrToken)
+	case tr.TokenType != "" && !strings.EqualFold(tr.TokenType, tokenTypeBearer):
+		return fmt.Errorf("%w: token type: %s", ErrToken, tr.TokenType)
+	}
+
+	ts.token, ts.expires = tr.AccessToken, time.Time{}
+
+	if tr.ExpiresIn > 0 {
+		ttl := time.Duration(tr.ExpiresIn) * time.Second
+
+		ts.expires = time.Now().Add(ttl - min(tokenSkew, ttl/2))
+	}
+
+	return nil
+}
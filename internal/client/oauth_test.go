@@ -0,0 +1,172 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer is a fake OAuth2 server, it issues tokens for client credentials grant and
+// accepts only last issued token for api requests.
+type tokenServer struct {
+	mu      sync.Mutex
+	current string
+	issued  atomic.Int32
+}
+
+func (ts *tokenServer) revoke() {
+	ts.mu.Lock()
+	ts.current = ""
+	ts.mu.Unlock()
+}
+
+func (ts *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/token" {
+		ts.mu.Lock()
+		ok := ts.current != "" && r.Header.Get(headerAuthorize) == "Bearer "+ts.current
+		ts.mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+
+		return
+	}
+
+	// credentials are url-encoded, as RFC 6749 (section 2.3.1) requires
+	id, secret, _ := r.BasicAuth()
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+
+	if r.Method != http.MethodPost || r.FormValue("grant_type") != grantClientCredentials {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"unsupported_grant_type"}`)
+
+		return
+	}
+
+	switch {
+	case id != "id" || secret != "s:cret":
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error":"invalid_client"}`)
+	case r.FormValue("scope") == "mac":
+		_, _ = io.WriteString(w, `{"access_token":"x","token_type":"mac"}`)
+	case r.FormValue("scope") == "none":
+		_, _ = io.WriteString(w, `{"token_type":"bearer"}`)
+	case r.FormValue("scope") == "bad":
+		_, _ = io.WriteString(w, `{`)
+	default:
+		token := "t" + strconv.Itoa(int(ts.issued.Add(1)))
+
+		ts.mu.Lock()
+		ts.current = token
+		ts.mu.Unlock()
+
+		_, _ = fmt.Fprintf(w, `{"access_token":"%s","token_type":"Bearer","expires_in":3600}`, token)
+	}
+}
+
+func TestHTTPOAuth(t *testing.T) {
+	t.Parallel()
+
+	ts := &tokenServer{}
+	srv := httptest.NewServer(ts)
+
+	defer srv.Close()
+
+	tc := cfg
+	tc.OAuth = &OAuth{
+		TokenURL:     srv.URL + "/token",
+		ClientID:     "id",
+		ClientSecret: "s:cret",
+		Scopes:       []string{"read", "list"},
+	}
+
+	c := New(&tc)
+
+	if err := c.Login(t.Context()); err != nil {
+		t.Fatal("login:", err)
+	}
+
+	for range 3 {
+		if _, err := c.Head(t.Context(), srv.URL+"/api"); err != nil {
+			t.Fatal("head:", err)
+		}
+	}
+
+	if n := ts.issued.Load(); n != 1 {
+		t.Error("unexpected tokens issued:", n)
+	}
+
+	// rejected token is refreshed
+	ts.revoke()
+
+	if _, err := c.Head(t.Context(), srv.URL+"/api"); err != nil {
+		t.Fatal("revoked:", err)
+	}
+
+	if n := ts.issued.Load(); n != 2 {
+		t.Error("revoked - unexpected tokens issued:", n)
+	}
+
+	// expired token is refreshed
+	c.oauth.mu.Lock()
+	c.oauth.expires = time.Now().Add(-time.Second)
+	c.oauth.mu.Unlock()
+
+	if _, err := c.Head(t.Context(), srv.URL+"/api"); err != nil {
+		t.Fatal("expired:", err)
+	}
+
+	if n := ts.issued.Load(); n != 3 {
+		t.Error("expired - unexpected tokens issued:", n)
+	}
+}
+
+func TestHTTPOAuthErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(&tokenServer{})
+
+	defer srv.Close()
+
+	for name, o := range map[string]*OAuth{
+		"client": {TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "bad"},
+		"type":   {TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "s:cret", Scopes: []string{"mac"}},
+		"empty":  {TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "s:cret", Scopes: []string{"none"}},
+	} {
+		tc := cfg
+		tc.OAuth = o
+
+		if err := New(&tc).Login(t.Context()); !errors.Is(err, ErrToken) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	for name, o := range map[string]*OAuth{
+		"json": {TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "s:cret", Scopes: []string{"bad"}},
+		"url":  {TokenURL: "%"},
+		"do":   {TokenURL: "http://127.0.0.1:1/token"},
+	} {
+		tc := cfg
+		tc.OAuth = o
+
+		c := New(&tc)
+
+		if err := c.Login(t.Context()); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+
+		if _, err := c.Head(t.Context(), srv.URL+"/api"); err == nil {
+			t.Errorf("%s: head - no error", name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxRedirects is a max count of redirects, followed for single request.
+const DefaultMaxRedirects = 10
+
+// RedirectFunc decides, if redirect (caused by response with given status) to url should be followed.
+// If it is not, redirect response itself is returned to caller.
+type RedirectFunc func(status int, to *url.URL) (follow bool)
+
+type redirectKey struct{}
+
+// WithRedirectFunc returns context, that carries redirect policy for requests made with it.
+func WithRedirectFunc(ctx context.Context, fn RedirectFunc) context.Context {
+	return context.WithValue(ctx, redirectKey{}, fn)
+}
+
+// checkRedirect returns http.Client.CheckRedirect hook, that caps redirects count and
+// applies request policy (if any), stopped redirect returns its own response.
+func checkRedirect(limit int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > limit {
+			return http.ErrUseLastResponse
+		}
+
+		fn, ok := req.Context().Value(redirectKey{}).(RedirectFunc)
+		if !ok {
+			return nil
+		}
+
+		var status int
+
+		if req.Response != nil {
+			status = req.Response.StatusCode
+		}
+
+		if !fn(status, req.URL) {
+			return http.ErrUseLastResponse
+		}
+
+		return nil
+	}
+}
+
+// redirectLimit converts configured value: zero - default, negative - do not follow redirects.
+func redirectLimit(n int) (rv int) {
+	switch {
+	case n == 0:
+		return DefaultMaxRedirects
+	case n < 0:
+		return 0
+	}
+
+	return n
+}
+
+func isRedirect(code int) (yes bool) {
+	return code >= http.StatusMultipleChoices && code < http.StatusBadRequest
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRedirectLimit(t *testing.T) {
+	t.Parallel()
+
+	for have, want := range map[int]int{
+		0:  DefaultMaxRedirects,
+		-5: 0,
+		3:  3,
+	} {
+		if got := redirectLimit(have); got != want {
+			t.Errorf("%d: want: %d got: %d", have, want, got)
+		}
+	}
+}
+
+func TestHTTPRedirects(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		}
+	}))
+
+	defer ts.Close()
+
+	type hop struct {
+		to     string
+		status int
+	}
+
+	head := func(limit int, fn RedirectFunc) (code int, final string, hdrs http.Header) {
+		tc := cfg
+		tc.MaxRedirects = limit
+
+		ctx := t.Context()
+		if fn != nil {
+			ctx = WithRedirectFunc(ctx, fn)
+		}
+
+		resp, err := New(&tc).Head(ctx, ts.URL+"/a")
+
+		var herr HTTPError
+
+		if err != nil && (!errors.As(err, &herr) || herr.Code() != resp.Code) {
+			t.Fatal(err)
+		}
+
+		return resp.Code, resp.URL.Path, resp.Header
+	}
+
+	var hops []hop
+
+	record := func(status int, to *url.URL) bool {
+		hops = append(hops, hop{to: to.Path, status: status})
+
+		return to.Path != "/c"
+	}
+
+	if code, final, hdrs := head(0, record); code != http.StatusFound || final != "/b" || hdrs.Get("Location") != "/c" {
+		t.Error("policy - unexpected response:", code, final, hdrs)
+	}
+
+	if len(hops) != 2 || hops[0] != (hop{"/b", http.StatusMovedPermanently}) || hops[1] != (hop{"/c", http.StatusFound}) {
+		t.Error("policy - unexpected hops:", hops)
+	}
+
+	if code, final, _ := head(0, nil); code != http.StatusOK || final != "/c" {
+		t.Error("default - unexpected status:", code)
+	}
+
+	if code, _, _ := head(1, nil); code != http.StatusFound {
+		t.Error("limit - unexpected status:", code)
+	}
+
+	if code, _, _ := head(-1, nil); code != http.StatusMovedPermanently {
+		t.Error("disabled - unexpected status:", code)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Response holds metadata of received response.
+type Response struct {
+	// URL is a final url, after all followed redirects.
+	URL *url.URL
+	// Header holds response headers.
+	Header http.Header
+	// Reason is a reason phrase, i.e. "Not Found".
+	Reason string
+	// Code is an exact status code, 0 - if no response was received.
+	Code int
+}
+
+func newResponse(resp *http.Response) (rv *Response) {
+	rv = &Response{
+		Header: resp.Header,
+		Code:   resp.StatusCode,
+		Reason: strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+	}
+
+	if rv.Reason == "" {
+		rv.Reason = http.StatusText(resp.StatusCode)
+	}
+
+	if resp.Request != nil {
+		rv.URL = resp.Request.URL
+	}
+
+	return rv
+}
+
+// Success reports, if response has 2xx status code.
+func (r *Response) Success() (yes bool) {
+	return r.Code >= http.StatusOK && r.Code < http.StatusMultipleChoices
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNoCerts is returned, when CA bundle has no certificates.
+var ErrNoCerts = errors.New("no certificates found")
+
+// LoadCert loads client certificate from PEM files, key can be stored along with certificate, in single file.
+func LoadCert(certFile, keyFile string) (c *tls.Certificate, err error) {
+	if keyFile == "" {
+		keyFile = certFile
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	return &cert, nil
+}
+
+// LoadCA loads CA bundle from PEM file, certificates are added to system ones.
+func LoadCA(name string) (pool *x509.CertPool, err error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	if pool, err = x509.SystemCertPool(); err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: %w", name, ErrNoCerts)
+	}
+
+	return pool, nil
+}
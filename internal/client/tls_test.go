@@ -0,0 +1,117 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeClientCert(t *testing.T, dir string) (cert, key string) {
+	t.Helper()
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &pk.PublicKey, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kder, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, key = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	_ = os.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: kder}), 0o600)
+
+	return cert, key
+}
+
+func TestHTTPTLS(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.StartTLS()
+
+	defer ts.Close()
+
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+
+	_ = os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+
+	tc := cfg
+
+	if _, err := New(&tc).Head(t.Context(), ts.URL); err == nil {
+		t.Error("unknown ca - no error")
+	}
+
+	pool, err := LoadCA(ca)
+	if err != nil {
+		t.Fatal("ca:", err)
+	}
+
+	tc.RootCAs = pool
+
+	if _, err = New(&tc).Head(t.Context(), ts.URL); err == nil {
+		t.Error("no client cert - no error")
+	}
+
+	if tc.Cert, err = LoadCert(writeClientCert(t, dir)); err != nil {
+		t.Fatal("cert:", err)
+	}
+
+	if _, err = New(&tc).Head(t.Context(), ts.URL); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestLoadTLSErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.pem")
+
+	_ = os.WriteFile(bad, []byte("not a pem"), 0o600)
+
+	if _, err := LoadCA(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ca missing - no error")
+	}
+
+	if _, err := LoadCA(bad); !errors.Is(err, ErrNoCerts) {
+		t.Error("ca bad - unexpected error:", err)
+	}
+
+	if _, err := LoadCert(bad, ""); err == nil {
+		t.Error("cert bad - no error")
+	}
+}
@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/s0rg/set"
+)
+
+// anchorSet collects anchors of crawled pages and links to them, to find broken ones, after crawl.
+type anchorSet struct {
+	pages map[string]set.Set[string] // page url -> its anchors
+	refs  refsMap                    // "page#fragment" -> pages, link was found at
+	mu    sync.Mutex
+}
+
+func newAnchorSet() (a *anchorSet) {
+	return &anchorSet{
+		pages: make(map[string]set.Set[string]),
+		refs:  make(refsMap),
+	}
+}
+
+// AddPage records anchors of page, it can be known by many urls (i.e. if it was redirected).
+func (a *anchorSet) AddPage(names set.Set[string], uris ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, u := range uris {
+		a.pages[u] = names
+	}
+}
+
+// AddRef records link with fragment, fragments, that are not anchors, are skipped.
+func (a *anchorSet) AddRef(uri, fragment, source string) {
+	if !isAnchor(fragment) {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.refs.Add(uri+"#"+fragment, source)
+}
+
+// Broken returns links to missing anchors, sorted by url, links to pages, that were not crawled, are skipped.
+func (a *anchorSet) Broken() (rv []Broken) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, lr := range a.refs {
+		uri, fragment, _ := strings.Cut(key, "#")
+
+		if names, ok := a.pages[uri]; !ok || names.Has(fragment) {
+			continue
+		}
+
+		rv = insertBroken(rv, Broken{
+			URL:     key,
+			Error:   "no such anchor: " + fragment,
+			Sources: lr.sources,
+			Refs:    lr.seen.Len(),
+		})
+	}
+
+	return rv
+}
+
+// isAnchor reports, if fragment points to anchor: "top" is always valid, while client-side routes
+// (i.e. "#/path", "#!path") and text fragments are not anchors at all.
+func isAnchor(fragment string) (yes bool) {
+	switch {
+	case fragment == "", strings.EqualFold(fragment, "top"):
+		return false
+	case strings.HasPrefix(fragment, "/"), strings.HasPrefix(fragment, "!"), strings.Contains(fragment, ":~:"):
+		return false
+	}
+
+	return true
+}
+
+// BrokenAnchors returns links to missing anchors, found during last crawl, sorted by url.
+func (c *Crawler) BrokenAnchors() []Broken {
+	if c.anchors == nil {
+		return nil
+	}
+
+	return c.anchors.Broken()
+}
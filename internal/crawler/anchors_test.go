@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/s0rg/set"
+)
+
+func TestIsAnchor(t *testing.T) {
+	t.Parallel()
+
+	for have, want := range map[string]bool{
+		"":              false,
+		"top":           false,
+		"Top":           false,
+		"/route":        false,
+		"!route":        false,
+		":~:text=hello": false,
+		"install":       true,
+		"section:1":     true,
+	} {
+		if got := isAnchor(have); got != want {
+			t.Errorf("%q: want: %t got: %t", have, want, got)
+		}
+	}
+}
+
+func TestAnchorSet(t *testing.T) {
+	t.Parallel()
+
+	a := newAnchorSet()
+
+	names := make(set.Unordered[string])
+	names.Add("install")
+
+	a.AddPage(names, "http://test/old", "http://test/new")
+
+	a.AddRef("http://test/new", "install", "http://test/")
+	a.AddRef("http://test/old", "usage", "http://test/")
+	a.AddRef("http://test/old", "usage", "http://test/a")
+	a.AddRef("http://test/old", "usage", "http://test/a")
+	a.AddRef("http://test/old", "top", "http://test/")
+	a.AddRef("http://test/other", "nope", "http://test/")
+
+	b := a.Broken()
+
+	if len(b) != 1 {
+		t.Fatal("unexpected broken:", b)
+	}
+
+	if b[0].URL != "http://test/old#usage" || b[0].Refs != 2 || len(b[0].Sources) != 2 {
+		t.Errorf("unexpected broken: %+v", b[0])
+	}
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	limitPages    = "pages"
+	limitURLs     = "urls"
+	limitBytes    = "bytes"
+	limitDuration = "duration"
+)
+
+// budget tracks crawl limits, crawl is stopped, once any of them is exceeded.
+type budget struct {
+	stop     context.CancelCauseFunc
+	pages    atomic.Int64
+	bytes    atomic.Int64
+	urls     int64
+	maxPages int64
+	maxURLs  int64
+	maxBytes int64
+}
+
+func newBudget(cfg *config, stop context.CancelCauseFunc) *budget {
+	return &budget{
+		stop:     stop,
+		maxPages: int64(cfg.MaxPages),
+		maxURLs:  int64(cfg.MaxURLs),
+		maxBytes: cfg.MaxBytes,
+	}
+}
+
+// TakePage reserves single page fetch, returns false and stops crawl, if pages or bytes budget is exceeded.
+func (b *budget) TakePage() (ok bool) {
+	if b.maxBytes > 0 && b.bytes.Load() >= b.maxBytes {
+		b.stop(LimitError{Limit: limitBytes, Max: strconv.FormatInt(b.maxBytes, 10)})
+
+		return false
+	}
+
+	if b.maxPages > 0 && b.pages.Add(1) > b.maxPages {
+		b.stop(LimitError{Limit: limitPages, Max: strconv.FormatInt(b.maxPages, 10)})
+
+		return false
+	}
+
+	return true
+}
+
+// TakeURL reserves single url for output, returns false and stops crawl, if urls budget is exceeded.
+// It is not thread-safe, as urls are emitted from single goroutine.
+func (b *budget) TakeURL() (ok bool) {
+	if b.maxURLs > 0 && b.urls >= b.maxURLs {
+		b.stop(LimitError{Limit: limitURLs, Max: strconv.FormatInt(b.maxURLs, 10)})
+
+		return false
+	}
+
+	b.urls++
+
+	return true
+}
+
+// Body wraps response body, to count bytes read from it.
+func (b *budget) Body(rc io.ReadCloser) *countingBody {
+	return &countingBody{ReadCloser: rc, n: &b.bytes}
+}
+
+type countingBody struct {
+	io.ReadCloser
+
+	n    *atomic.Int64
+	size int64 // bytes read from this body
+}
+
+func (cb *countingBody) Read(p []byte) (n int, err error) {
+	n, err = cb.ReadCloser.Read(p)
+	cb.n.Add(int64(n))
+	cb.size += int64(n)
+
+	return n, err
+}
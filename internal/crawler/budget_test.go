@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBudgetUnlimited(t *testing.T) {
+	t.Parallel()
+
+	var stopped bool
+
+	b := newBudget(&config{}, func(error) { stopped = true })
+
+	for range 100 {
+		if !b.TakePage() || !b.TakeURL() {
+			t.Fatal("unexpected limit")
+		}
+	}
+
+	if stopped {
+		t.Error("stopped")
+	}
+}
+
+func TestBudgetLimits(t *testing.T) {
+	t.Parallel()
+
+	var cause error
+
+	stop := func(err error) { cause = err }
+
+	b := newBudget(&config{MaxPages: 2, MaxURLs: 1}, stop)
+
+	if !b.TakePage() || !b.TakePage() {
+		t.Fatal("pages: early limit")
+	}
+
+	if b.TakePage() {
+		t.Error("pages: no limit")
+	}
+
+	var lerr LimitError
+
+	if !errors.As(cause, &lerr) || lerr.Limit != limitPages || lerr.Max != "2" {
+		t.Error("pages: unexpected cause:", cause)
+	}
+
+	if !b.TakeURL() {
+		t.Fatal("urls: early limit")
+	}
+
+	if b.TakeURL() {
+		t.Error("urls: no limit")
+	}
+
+	if lerr.Error() != "max pages limit reached: 2" {
+		t.Error("unexpected message:", lerr.Error())
+	}
+
+	if !errors.As(cause, &lerr) || lerr.Limit != limitURLs {
+		t.Error("urls: unexpected cause:", cause)
+	}
+}
+
+func TestBudgetBytes(t *testing.T) {
+	t.Parallel()
+
+	var cause error
+
+	b := newBudget(&config{MaxBytes: 4}, func(err error) { cause = err })
+
+	if !b.TakePage() {
+		t.Fatal("early limit")
+	}
+
+	n, err := io.Copy(io.Discard, b.Body(io.NopCloser(strings.NewReader("12345"))))
+	if err != nil || n != 5 {
+		t.Fatal("copy:", n, err)
+	}
+
+	if b.TakePage() {
+		t.Error("no limit")
+	}
+
+	var lerr LimitError
+
+	if !errors.As(cause, &lerr) || lerr.Limit != limitBytes {
+		t.Error("unexpected cause:", cause)
+	}
+}
+
+func TestStopError(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithCancelCause(t.Context())
+	stop(LimitError{Limit: limitDuration, Max: "1s"})
+
+	var lerr LimitError
+
+	if !errors.As(stopError(ctx), &lerr) {
+		t.Error("not a limit error")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if !errors.Is(stopError(ctx), context.Canceled) {
+		t.Error("not a cancel error")
+	}
+}
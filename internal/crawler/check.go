@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/s0rg/set"
+
+	"github.com/s0rg/crawley/internal/client"
+)
+
+const maxSources = 10
+
+// Broken describes link, that answered with 4xx / 5xx status, or could not be fetched at all.
+type Broken struct {
+	URL     string   `json:"url"`
+	Error   string   `json:"error"`
+	Sources []string `json:"sources,omitempty"` // pages, link was found at (first ones)
+	Status  int      `json:"status,omitempty"`
+	Refs    int      `json:"refs"` // total count of pages, link was found at
+}
+
+// linkRefs holds pages, link was found at.
+type linkRefs struct {
+	seen    set.Set[uint64]
+	sources []string
+}
+
+// refsMap keeps references for every found link, it is owned by crawl loop.
+type refsMap map[string]*linkRefs
+
+func (m refsMap) Add(uri, source string) {
+	if m == nil || source == "" {
+		return
+	}
+
+	lr, ok := m[uri]
+	if !ok {
+		lr = &linkRefs{seen: make(set.Unordered[uint64])}
+		m[uri] = lr
+	}
+
+	if !lr.seen.Add(urlhash(source)) {
+		return
+	}
+
+	if len(lr.sources) < maxSources {
+		lr.sources = append(lr.sources, source)
+	}
+}
+
+// isBroken reports, if request result means broken link.
+func isBroken(status int, err error) (yes bool) {
+	return err != nil && (status == 0 || status >= http.StatusBadRequest)
+}
+
+// tryCheck creates task, that only checks url status, for found url, that cannot be crawled.
+func (c *Crawler) tryCheck(r *crawlResult) (t *crawlTask, yes bool) {
+	u, ok := c.checkable(r)
+	if !ok {
+		return
+	}
+
+	res := r.Result
+
+	t = newTask(u)
+	t.Depth = r.Depth
+	t.Seed = r.Seed
+	t.Result = &res
+	t.Check = true
+
+	return t, true
+}
+
+// checkable parses found url, and reports, if it can be checked.
+func (c *Crawler) checkable(r *crawlResult) (u *url.URL, yes bool) {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+
+	s := c.seeds[r.Seed]
+
+	// links, which are not crawled on purpose, are not checked as well
+	if c.isIgnored(r.URL) || !c.avoid.Allow(r.URL) ||
+		(strings.EqualFold(s.URL.Host, u.Host) && s.robots.Forbidden(u.Path)) {
+		return nil, false
+	}
+
+	return u, true
+}
+
+// check requests url only to get its status: with HEAD, or with GET, if HEAD is not allowed. Redirects out
+// of crawl scope are not followed with crawl credentials, their targets are checked with bare client.
+func (c *Crawler) check(
+	ctx context.Context,
+	web crawlClient,
+	task *crawlTask,
+	hops *[]Redirect,
+) (resp *client.Response, err error) {
+	if web = c.checkClient(web, task); web == c.bare {
+		return checkURL(client.WithRedirectFunc(ctx, recordRedirects(hops, nil)), web, task.URI)
+	}
+
+	s := c.seeds[task.Seed].URL
+	inScope := func(u *url.URL) bool {
+		return c.bare == nil || c.scope.Host(s, u)
+	}
+
+	resp, err = checkURL(client.WithRedirectFunc(ctx, recordRedirects(hops, inScope)), web, task.URI)
+
+	c.checkSession(task.URI, err)
+
+	if u, ok := redirectTarget(task.URL, resp); ok && !inScope(u) {
+		us := u.String()
+
+		*hops = append(*hops, Redirect{URL: us, Status: resp.Code})
+
+		return checkURL(client.WithRedirectFunc(ctx, recordRedirects(hops, nil)), c.bare, us)
+	}
+
+	return resp, err
+}
+
+// checkURL requests url with HEAD, or with GET, if HEAD is not allowed.
+func checkURL(ctx context.Context, web crawlClient, uri string) (resp *client.Response, err error) {
+	if resp, err = web.Head(ctx, uri); resp.Code == http.StatusMethodNotAllowed {
+		var body io.ReadCloser
+
+		if body, resp, err = web.Get(ctx, uri); body != nil {
+			client.Discard(body)
+		}
+	}
+
+	return resp, err
+}
+
+// newBareClient creates client for hosts, that are out of crawl scope: it knows nothing about crawl
+// credentials (auth, oauth, client certificate, headers and cookies), only proxy ones are kept.
+func newBareClient(cfg *config, workers, hostConns int, hostRate float64) (web *client.HTTP) {
+	return client.New(&client.Config{
+		UserAgent:    cfg.Client.UserAgent,
+		Timeout:      cfg.Client.Timeout,
+		SkipSSL:      cfg.Client.SkipSSL,
+		ProxyAuth:    cfg.Client.ProxyAuth,
+		RootCAs:      cfg.Client.RootCAs,
+		MaxRedirects: cfg.Client.MaxRedirects,
+		Workers:      workers,
+		HostConns:    hostConns,
+		HostRate:     hostRate,
+	})
+}
+
+// checkClient returns client for check task: links to hosts out of crawl scope are checked without credentials.
+func (c *Crawler) checkClient(web crawlClient, task *crawlTask) crawlClient {
+	if c.bare == nil || c.scope.Host(c.seeds[task.Seed].URL, task.URL) {
+		return web
+	}
+
+	return c.bare
+}
+
+// recordRedirects returns redirect policy, that follows redirects, allowed by fn (every one, if it is nil),
+// recording its chain.
+func recordRedirects(hops *[]Redirect, fn func(*url.URL) bool) client.RedirectFunc {
+	return func(status int, to *url.URL) (follow bool) {
+		if fn != nil && !fn(to) {
+			return false
+		}
+
+		*hops = append(*hops, Redirect{URL: to.String(), Status: status})
+
+		return true
+	}
+}
+
+// Broken returns links, found broken during last crawl (in check mode), sorted by url.
+func (c *Crawler) Broken() []Broken {
+	return c.refs.Fill(c.broken)
+}
+
+// Fill sets pages, links were found at, as they can be found on more pages, after they were checked.
+func (m refsMap) Fill(b []Broken) []Broken {
+	for i := range b {
+		if lr, ok := m[b[i].URL]; ok {
+			b[i].Sources, b[i].Refs = lr.sources, lr.seen.Len()
+		}
+	}
+
+	return b
+}
+
+func (c *Crawler) addBroken(r *crawlResult) {
+	c.broken = insertBroken(c.broken, Broken{
+		URL:    r.URL,
+		Error:  r.Err.Error(),
+		Status: r.Status,
+	})
+}
+
+// insertBroken inserts b, keeping list sorted by url.
+func insertBroken(list []Broken, b Broken) []Broken {
+	idx, _ := slices.BinarySearchFunc(list, b.URL, func(v Broken, u string) int {
+		return strings.Compare(v.URL, u)
+	})
+
+	return slices.Insert(list, idx, b)
+}
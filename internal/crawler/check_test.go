@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestRefsMap(t *testing.T) {
+	t.Parallel()
+
+	var none refsMap
+
+	none.Add("a", "b") // must be safe
+
+	m := make(refsMap)
+
+	for i := range maxSources + 5 {
+		m.Add("a", "src"+strconv.Itoa(i))
+		m.Add("a", "src"+strconv.Itoa(i))
+	}
+
+	m.Add("b", "")
+
+	if lr := m["a"]; lr.seen.Len() != maxSources+5 || len(lr.sources) != maxSources {
+		t.Errorf("unexpected refs: %+v", lr)
+	}
+
+	if _, ok := m["b"]; ok {
+		t.Error("empty source is recorded")
+	}
+}
+
+func TestIsBroken(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		err    error
+		status int
+		want   bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusNotFound},
+		{status: http.StatusFound, err: errGeneric},
+		{status: 0, err: errGeneric, want: true},
+		{status: http.StatusGone, err: errGeneric, want: true},
+		{status: http.StatusBadGateway, err: errGeneric, want: true},
+	} {
+		if got := isBroken(tc.status, tc.err); got != tc.want {
+			t.Errorf("%d %v: want: %t got: %t", tc.status, tc.err, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/s0rg/set"
+)
+
+// state holds crawling progress: seen urls hashes, pending (enqueued, but not yet crawled) tasks
+// and results, that were not emitted due to exceeded urls budget.
+type state struct {
+	seen    set.Set[uint64]
+	pending map[string]*crawlTask
+	results []*Result
+}
+
+func newState(seeds []*url.URL) (st *state) {
+	st = &state{
+		seen:    make(set.Unordered[uint64]),
+		pending: make(map[string]*crawlTask),
+	}
+
+	for i, u := range seeds {
+		if !st.seen.Add(urlhash(u.String())) {
+			continue
+		}
+
+		t := newTask(u)
+		t.Seed = i
+
+		st.Add(t)
+	}
+
+	return st
+}
+
+// Add adds task to pending, returns false if task is already there.
+func (st *state) Add(t *crawlTask) (ok bool) {
+	if _, ok = st.pending[t.URI]; ok {
+		return false
+	}
+
+	st.pending[t.URI] = t
+
+	return true
+}
+
+// Frontier returns pending tasks, that should be crawled first.
+func (st *state) Frontier() (rv []*crawlTask) {
+	return slices.Collect(maps.Values(st.pending))
+}
+
+// checkpoint is a serializable crawling state.
+type checkpoint struct {
+	Seeds    []string     `json:"seeds"`
+	Frontier []*crawlTask `json:"frontier"`
+	Results  []*Result    `json:"results,omitempty"`
+	Seen     []uint64     `json:"seen"`
+	Config   config       `json:"config"`
+}
+
+func loadCheckpoint(name string) (cp *checkpoint, err error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	defer fd.Close()
+
+	cp = &checkpoint{}
+
+	if err = json.NewDecoder(fd).Decode(cp); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	return cp, nil
+}
+
+// Save atomically writes checkpoint to file with given name.
+func (cp *checkpoint) Save(name string) (err error) {
+	fd, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(fd.Name())
+		}
+	}()
+
+	if err = json.NewEncoder(fd).Encode(cp); err != nil {
+		_ = fd.Close()
+
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if err = fd.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	if err = os.Rename(fd.Name(), name); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	return nil
+}
+
+// SetState fills checkpoint from crawling state.
+func (cp *checkpoint) SetState(st *state) {
+	cp.Seen = set.ToSlice(st.seen)
+	cp.Frontier = st.Frontier()
+	cp.Results = st.results
+}
+
+// State builds crawling state from checkpoint.
+func (cp *checkpoint) State() (st *state, err error) {
+	st = &state{
+		seen:    set.Load(make(set.Unordered[uint64]), cp.Seen...),
+		pending: make(map[string]*crawlTask, len(cp.Frontier)),
+		results: cp.Results,
+	}
+
+	for _, t := range cp.Frontier {
+		if t.URL, err = url.Parse(t.URI); err != nil {
+			return nil, fmt.Errorf("parse frontier url: %w", err)
+		}
+
+		st.Add(t)
+	}
+
+	return st, nil
+}
@@ -0,0 +1,386 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/s0rg/set"
+)
+
+func TestCheckpointSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "state.json")
+	base, _ := url.Parse("http://test/")
+
+	st := newState([]*url.URL{base})
+	st.seen.Add(urlhash("http://test/a"))
+
+	u, _ := url.Parse("http://test/a")
+	task := newTask(u)
+	task.Depth = 1
+	task.Result = &Result{URL: "http://test/a", Source: base.String(), Depth: 1}
+
+	if !st.Add(task) || st.Add(task) {
+		t.Fatal("unexpected add result")
+	}
+
+	cp := checkpoint{
+		Seeds:  []string{base.String()},
+		Config: config{Depth: 3, Checkpoint: name},
+	}
+
+	cp.SetState(st)
+
+	if err := cp.Save(name); err != nil {
+		t.Fatal("save:", err)
+	}
+
+	got, err := loadCheckpoint(name)
+	if err != nil {
+		t.Fatal("load:", err)
+	}
+
+	if len(got.Seeds) != 1 || got.Seeds[0] != base.String() || got.Config.Depth != 3 {
+		t.Error("unexpected values")
+	}
+
+	if got.Config.Checkpoint != "" {
+		t.Error("checkpoint name saved")
+	}
+
+	rst, err := got.State()
+	if err != nil {
+		t.Fatal("state:", err)
+	}
+
+	if rst.seen.Len() != 2 || !rst.seen.Has(urlhash("http://test/a")) {
+		t.Error("unexpected seen")
+	}
+
+	if len(rst.Frontier()) != 2 {
+		t.Fatal("unexpected frontier")
+	}
+
+	rt, ok := rst.pending["http://test/a"]
+	if !ok || rt.URL == nil || rt.Depth != 1 || rt.Result == nil || rt.Result.Source != base.String() {
+		t.Error("unexpected task")
+	}
+}
+
+func TestCheckpointLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	if _, err := loadCheckpoint(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing - no error")
+	}
+
+	bad := filepath.Join(dir, "bad")
+	_ = os.WriteFile(bad, []byte("{"), 0o600)
+
+	if _, err := loadCheckpoint(bad); err == nil {
+		t.Error("bad - no error")
+	}
+
+	cp := checkpoint{Frontier: []*crawlTask{{URI: "%"}}}
+
+	if _, err := cp.State(); err == nil {
+		t.Error("frontier - no error")
+	}
+
+	c := New()
+
+	if _, err := c.Restore(bad); err == nil {
+		t.Error("restore - no error")
+	}
+
+	if err := cp.Save(filepath.Join(dir, "no", "such", "dir")); err == nil {
+		t.Error("save - no error")
+	}
+
+	check := filepath.Join(dir, "check")
+	cp = checkpoint{Seeds: []string{"http://test/"}, Config: config{Check: true}}
+
+	if err := cp.Save(check); err != nil {
+		t.Fatal("save check:", err)
+	}
+
+	if _, err := c.Restore(check); !errors.Is(err, ErrCheckResume) {
+		t.Error("check - unexpected error:", err)
+	}
+}
+
+func TestCrawlerCheckpointResume(t *testing.T) {
+	t.Parallel()
+
+	var pages = map[string]string{
+		"/":  `<html><a href="/a">a</a><a href="/b">b</a></html>`,
+		"/a": `<html><a href="/a/1">1</a><a href="/a/2">2</a></html>`,
+		"/b": `<html><a href="/b/1">1</a><img src="/b/2.png"/></html>`,
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI == "/a" {
+			cancel()
+		}
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, pages[r.RequestURI])
+	}))
+
+	defer ts.Close()
+
+	name := filepath.Join(t.TempDir(), "state.json")
+	opts := []Option{
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(1),
+		WithCheckpoint(name),
+	}
+
+	first := make(set.Unordered[string])
+
+	c1 := New(opts...)
+
+	err := c1.RunContext(ctx, ts.URL, func(s string) {
+		first.Add(s)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("first run - unexpected error:", err)
+	}
+
+	c2 := New(WithCheckpoint(name))
+
+	seeds, err := c2.Restore(name)
+	if err != nil {
+		t.Fatal("restore:", err)
+	}
+
+	if len(seeds) != 1 || seeds[0] != ts.URL {
+		t.Fatal("unexpected seeds:", seeds)
+	}
+
+	second := make(set.Unordered[string])
+
+	if err = c2.RunContext(t.Context(), seeds[0], func(s string) {
+		if !second.Add(s) || first.Has(s) {
+			t.Error("duplicate result:", s)
+		}
+	}); err != nil {
+		t.Fatal("second run:", err)
+	}
+
+	if total := first.Len() + second.Len(); total != 6 {
+		t.Error("unexpected results count:", total)
+	}
+
+	cp, err := loadCheckpoint(name)
+	if err != nil {
+		t.Fatal("load:", err)
+	}
+
+	if len(cp.Frontier) != 0 {
+		t.Error("frontier not empty")
+	}
+}
+
+func TestCrawlerCheckpointResumeAll(t *testing.T) {
+	t.Parallel()
+
+	const (
+		fanout   = 3
+		maxLevel = 4
+		perRun   = 7
+	)
+
+	var (
+		stop  atomic.Pointer[context.CancelFunc]
+		count atomic.Int64
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cancel := stop.Load(); cancel != nil && count.Add(1)%perRun == 0 {
+			(*cancel)()
+		}
+
+		w.Header().Add(contentType, contentHTML)
+
+		p := strings.TrimSuffix(r.URL.Path, "/")
+		if strings.Count(p, "/") >= maxLevel {
+			return
+		}
+
+		for i := range fanout {
+			_, _ = fmt.Fprintf(w, `<a href="%s/%d">%d</a><img src="%s/%d.png"/>`, p, i, i, p, i)
+		}
+	}))
+
+	defer ts.Close()
+
+	opts := []Option{
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(4),
+	}
+
+	full := make(set.Unordered[string])
+
+	if err := New(opts...).RunContext(t.Context(), ts.URL, func(s string) {
+		full.Add(s)
+	}); err != nil {
+		t.Fatal("full run:", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "state.json")
+	got := make(set.Unordered[string])
+	seeds := []string{ts.URL}
+
+	for run := 0; ; run++ {
+		if run > full.Len() {
+			t.Fatal("too many runs")
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		stop.Store(&cancel)
+
+		c := New(append(opts, WithCheckpoint(name))...)
+
+		if run > 0 {
+			var err error
+
+			if seeds, err = c.Restore(name); err != nil {
+				t.Fatal("restore:", err)
+			}
+		}
+
+		err := c.CrawlSeeds(ctx, seeds, func(r *Result) {
+			if !got.Add(r.URL) {
+				t.Error("duplicate result:", r.URL)
+			}
+		})
+
+		cancel()
+
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	if got.Len() != full.Len() {
+		t.Fatalf("unexpected results count: %d, want: %d", got.Len(), full.Len())
+	}
+
+	full.Iter(func(s string) bool {
+		if !got.Has(s) {
+			t.Error("missing result:", s)
+		}
+
+		return true
+	})
+}
+
+func TestCrawlerCheckpointResumeMaxURLs(t *testing.T) {
+	t.Parallel()
+
+	const (
+		fanout   = 3
+		maxLevel = 3
+		perRun   = 4
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		p := strings.TrimSuffix(r.URL.Path, "/")
+		if strings.Count(p, "/") >= maxLevel {
+			return
+		}
+
+		for i := range fanout {
+			_, _ = fmt.Fprintf(w, `<a href="%s/%d">%d</a><img src="%s/%d.png"/>`, p, i, i, p, i)
+		}
+	}))
+
+	defer ts.Close()
+
+	opts := []Option{
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(4),
+	}
+
+	full := make(set.Unordered[string])
+
+	if err := New(opts...).RunContext(t.Context(), ts.URL, func(s string) {
+		full.Add(s)
+	}); err != nil {
+		t.Fatal("full run:", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "state.json")
+	got := make(set.Unordered[string])
+	seeds := []string{ts.URL}
+
+	for run := 0; ; run++ {
+		if run > full.Len() {
+			t.Fatal("too many runs")
+		}
+
+		c := New(append(opts, WithCheckpoint(name), WithMaxURLs(perRun))...)
+
+		if run > 0 {
+			var err error
+
+			if seeds, err = c.Restore(name); err != nil {
+				t.Fatal("restore:", err)
+			}
+		}
+
+		var n int
+
+		err := c.CrawlSeeds(t.Context(), seeds, func(r *Result) {
+			n++
+
+			if !got.Add(r.URL) {
+				t.Error("duplicate result:", r.URL)
+			}
+		})
+
+		if n > perRun {
+			t.Fatal("urls budget exceeded:", n)
+		}
+
+		if err == nil {
+			break
+		}
+
+		var lerr LimitError
+
+		if !errors.As(err, &lerr) || lerr.Limit != limitURLs {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	if got.Len() != full.Len() {
+		t.Fatalf("unexpected results count: %d, want: %d", got.Len(), full.Len())
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/s0rg/crawley/internal/client"
 )
 
+// DefaultFrontierSize is a default count of crawl tasks, kept in memory.
+const DefaultFrontierSize = 100_000
+
 const (
 	minDepth   = -1
 	minWorkers = 1
@@ -15,21 +18,46 @@ const (
 	minDelay   = time.Duration(0)
 	minTimeout = time.Second
 	maxTimeout = time.Minute * 10
+	minQueue   = 1
 )
 
 type config struct {
-	AlowedTags []string
-	Ignored    []string
-	Client     client.Config
-	Delay      time.Duration
-	Depth      int
-	Robots     RobotsPolicy
-	Dirs       DirsPolicy
-	Brute      bool
-	NoHEAD     bool
-	ScanJS     bool
-	ScanCSS    bool
-	Subdomains bool
+	AlowedTags      []string
+	Ignored         []string
+	Avoid           []string
+	CrawlInclude    []string
+	CrawlExclude    []string
+	OutputInclude   []string
+	OutputExclude   []string
+	ScopeHosts      []string
+	Checkpoint      string `json:"-"`
+	Client          client.Config
+	Delay           time.Duration
+	CheckpointEvery time.Duration `json:"-"`
+	MaxTime         time.Duration
+	RetryWait       time.Duration
+	ExternalRate    float64
+	MaxBytes        int64
+	Depth           int
+	Hops            int
+	FrontierSize    int
+	MaxPages        int
+	MaxURLs         int
+	Retries         int
+	ExternalWorkers int
+	Robots          RobotsPolicy
+	Dirs            DirsPolicy
+	Scope           ScopePolicy
+	Brute           bool
+	NoHEAD          bool
+	ScanJS          bool
+	ScanCSS         bool
+	Subdomains      bool
+	Graph           bool
+	NoAvoid         bool
+	Check           bool
+	CheckExternal   bool
+	CheckAnchors    bool
 }
 
 func (c *config) String() (rv string) {
@@ -37,14 +65,43 @@ func (c *config) String() (rv string) {
 
 	fmt.Fprintf(&sb, "workers: %d depth: %d timeout: %s", c.Client.Workers, c.Depth, c.Client.Timeout)
 
+	c.writeRequests(&sb)
+	c.writeScope(&sb)
+	c.writeSession(&sb)
+	c.writeChecks(&sb)
+	c.writeLimits(&sb)
+
+	return sb.String()
+}
+
+// writeRequests writes requests pacing settings.
+func (c *config) writeRequests(sb *strings.Builder) {
 	if c.Brute {
 		sb.WriteString(" brute: on")
 	}
 
 	if c.Delay > 0 {
-		fmt.Fprintf(&sb, " delay: %s", c.Delay)
+		fmt.Fprintf(sb, " delay: %s", c.Delay)
+	}
+
+	if c.Client.HostRate > 0 {
+		fmt.Fprintf(sb, " host-rate: %g", c.Client.HostRate)
+	}
+
+	if c.Client.HostConns > 0 {
+		fmt.Fprintf(sb, " host-conns: %d", c.Client.HostConns)
 	}
 
+	switch {
+	case c.Client.MaxRedirects < 0:
+		sb.WriteString(" redirects: off")
+	case c.Client.MaxRedirects != client.DefaultMaxRedirects:
+		fmt.Fprintf(sb, " max-redirects: %d", c.Client.MaxRedirects)
+	}
+}
+
+// writeScope writes settings, that affect which urls are crawled and printed.
+func (c *config) writeScope(sb *strings.Builder) {
 	if c.ScanJS {
 		sb.WriteString(" +js")
 	}
@@ -57,12 +114,145 @@ func (c *config) String() (rv string) {
 		sb.WriteString(" +subdomains")
 	}
 
-	return sb.String()
+	if n := len(c.CrawlInclude) + len(c.CrawlExclude); n > 0 {
+		fmt.Fprintf(sb, " crawl-rules: %d", n)
+	}
+
+	if n := len(c.OutputInclude) + len(c.OutputExclude); n > 0 {
+		fmt.Fprintf(sb, " output-rules: %d", n)
+	}
+
+	if c.Scope != ScopePath {
+		fmt.Fprintf(sb, " scope: %s", c.Scope)
+	}
+
+	if c.Graph {
+		sb.WriteString(" +graph")
+	}
+}
+
+// writeSession writes credentials and session handling settings.
+func (c *config) writeSession(sb *strings.Builder) {
+	if c.Client.Jar != nil {
+		sb.WriteString(" +jar")
+	}
+
+	if c.Client.Login != nil {
+		sb.WriteString(" +login")
+	}
+
+	if c.Client.Auth != nil {
+		fmt.Fprintf(sb, " auth: %s", c.Client.Auth.Scheme)
+	}
+
+	if c.Client.OAuth != nil {
+		sb.WriteString(" +oauth")
+	}
+
+	if n := len(c.Client.AuthHosts); n > 0 {
+		fmt.Fprintf(sb, " auth-hosts: %d", n)
+	}
+
+	if c.Client.Cert != nil {
+		sb.WriteString(" +cert")
+	}
+
+	if c.Client.RootCAs != nil {
+		sb.WriteString(" +ca")
+	}
+
+	switch {
+	case c.NoAvoid:
+		sb.WriteString(" avoid: off")
+	case len(c.Avoid) > 0:
+		fmt.Fprintf(sb, " avoid: %d", len(c.Avoid))
+	}
+
+	if c.Client.SessionCheck {
+		sb.WriteString(" +session-check")
+	}
+}
+
+// writeChecks writes links checks and retries settings.
+func (c *config) writeChecks(sb *strings.Builder) {
+	if c.Check {
+		sb.WriteString(" +check")
+	}
+
+	if c.CheckAnchors {
+		sb.WriteString(" +check-anchors")
+	}
+
+	if c.CheckExternal {
+		fmt.Fprintf(sb, " +check-external workers: %d rate: %g", c.ExternalWorkers, c.ExternalRate)
+	}
+
+	if c.Retries > 0 {
+		fmt.Fprintf(sb, " retries: %d wait: %s", c.Retries, c.RetryWait)
+	}
+}
+
+// writeLimits writes crawl budgets and checkpoint settings.
+func (c *config) writeLimits(sb *strings.Builder) {
+	if c.Hops > 0 {
+		fmt.Fprintf(sb, " hops: %d", c.Hops)
+	}
+
+	if c.MaxPages > 0 {
+		fmt.Fprintf(sb, " max-pages: %d", c.MaxPages)
+	}
+
+	if c.MaxURLs > 0 {
+		fmt.Fprintf(sb, " max-urls: %d", c.MaxURLs)
+	}
+
+	if c.MaxBytes > 0 {
+		fmt.Fprintf(sb, " max-bytes: %d", c.MaxBytes)
+	}
+
+	if c.MaxTime > 0 {
+		fmt.Fprintf(sb, " max-time: %s", c.MaxTime)
+	}
+
+	if c.Checkpoint != "" {
+		fmt.Fprintf(sb, " checkpoint: %s", c.Checkpoint)
+	}
 }
 
 func (c *config) validate() {
 	c.Client.Workers = min(maxWorkers, max(minWorkers, c.Client.Workers))
 	c.Client.Timeout = min(maxTimeout, max(minTimeout, c.Client.Timeout))
 	c.Delay = max(minDelay, c.Delay)
+	c.Client.HostRate = max(0, c.Client.HostRate)
+	c.Client.HostConns = max(0, c.Client.HostConns)
 	c.Depth = max(minDepth, c.Depth)
+	c.CheckpointEvery = max(minDelay, c.CheckpointEvery)
+	c.MaxTime = max(minDelay, c.MaxTime)
+	c.MaxBytes = max(0, c.MaxBytes)
+	c.Hops = max(0, c.Hops)
+	c.MaxPages = max(0, c.MaxPages)
+	c.MaxURLs = max(0, c.MaxURLs)
+	c.Retries = min(maxRetries, max(0, c.Retries))
+	c.ExternalRate = max(0, c.ExternalRate)
+
+	if c.ExternalWorkers <= 0 {
+		c.ExternalWorkers = DefaultExternalWorkers
+	}
+
+	c.ExternalWorkers = min(maxWorkers, c.ExternalWorkers)
+
+	if c.RetryWait <= 0 {
+		c.RetryWait = DefaultRetryWait
+	}
+
+	if c.FrontierSize < minQueue {
+		c.FrontierSize = DefaultFrontierSize
+	}
+
+	switch {
+	case c.Client.MaxRedirects == 0:
+		c.Client.MaxRedirects = client.DefaultMaxRedirects
+	case c.Client.MaxRedirects < 0:
+		c.Client.MaxRedirects = -1
+	}
 }
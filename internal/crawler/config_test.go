@@ -1,6 +1,8 @@
 package crawler
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"reflect"
 	"strings"
 	"testing"
@@ -78,6 +80,31 @@ func TestOptions(t *testing.T) {
 		WithScanJS(fbool),
 		WithIgnored([]string{"logout"}),
 		WithTimeout(timeout),
+		WithMaxHops(workers),
+		WithCookieJar(client.NewJar()),
+		WithCrawlRules([]string{"/docs/*"}, nil),
+		WithOutputRules(nil, []string{"*.png", "re:logout"}),
+		WithMaxPages(workers),
+		WithMaxURLs(depth),
+		WithMaxBytes(depth),
+		WithMaxDuration(delay),
+		WithAvoid([]string{"/bye"}),
+		WithSessionCheck(fbool),
+		WithCheck(fbool),
+		WithCheckExternal(fbool),
+		WithCheckAnchors(fbool),
+		WithExternalWorkers(maxWorkers + 1),
+		WithExternalRate(-1),
+		WithAuth(&client.Auth{Scheme: client.AuthDigest}),
+		WithOAuth(&client.OAuth{}),
+		WithAuthHosts([]string{"api.test"}),
+		WithHostRate(2.5),
+		WithHostConns(-1),
+		WithMaxRedirects(3),
+		WithRetries(maxRetries + 1),
+		WithRetryWait(-time.Second),
+		WithClientCert(&tls.Certificate{}),
+		WithRootCAs(x509.NewCertPool()),
 	}
 
 	c := &config{}
@@ -147,6 +174,73 @@ func TestOptions(t *testing.T) {
 	if c.Client.Timeout != timeout {
 		t.Error("bad timeout")
 	}
+
+	if len(c.CrawlInclude) != 1 || len(c.OutputExclude) != 2 {
+		t.Error("bad rules")
+	}
+
+	if c.Client.Jar == nil || !strings.Contains(c.String(), "+jar") {
+		t.Error("bad cookie jar")
+	}
+
+	if c.Hops != workers {
+		t.Error("bad hops")
+	}
+
+	if c.MaxPages != workers || c.MaxURLs != depth || c.MaxBytes != depth || c.MaxTime != delay {
+		t.Error("bad budgets")
+	}
+
+	if len(c.Avoid) != 1 || !strings.Contains(c.String(), "avoid: 1") {
+		t.Error("bad avoid")
+	}
+
+	if !c.Client.SessionCheck || !strings.Contains(c.String(), "+session-check") {
+		t.Error("bad session check")
+	}
+
+	if !c.Check || !strings.Contains(c.String(), "+check") {
+		t.Error("bad check")
+	}
+
+	if !c.CheckAnchors || !strings.Contains(c.String(), "+check-anchors") {
+		t.Error("bad check anchors")
+	}
+
+	if !c.CheckExternal || c.ExternalWorkers != maxWorkers || c.ExternalRate != 0 ||
+		!strings.Contains(c.String(), "+check-external workers: 64 rate: 0") {
+		t.Error("bad check external")
+	}
+
+	if v := c.String(); !strings.Contains(v, "auth: digest") || !strings.Contains(v, "+cert") ||
+		!strings.Contains(v, "+oauth") || !strings.Contains(v, "+ca") || !strings.Contains(v, "auth-hosts: 1") {
+		t.Error("bad auth / tls")
+	}
+
+	if c.Client.HostRate != 2.5 || c.Client.HostConns != 0 || !strings.Contains(c.String(), "host-rate: 2.5") {
+		t.Error("bad host limits")
+	}
+
+	if c.Client.MaxRedirects != 3 || !strings.Contains(c.String(), "max-redirects: 3") {
+		t.Error("bad max redirects")
+	}
+
+	WithMaxRedirects(-5)(c)
+	c.validate()
+
+	if c.Client.MaxRedirects != -1 || !strings.Contains(c.String(), "redirects: off") {
+		t.Error("bad no redirects")
+	}
+
+	if c.Retries != maxRetries || c.RetryWait != DefaultRetryWait || !strings.Contains(c.String(), "retries: 10") {
+		t.Error("bad retries")
+	}
+
+	WithoutAvoid(fbool)(c)
+
+	if !strings.Contains(c.String(), "avoid: off") {
+		t.Error("bad no-avoid")
+	}
 }
 
 func TestString(t *testing.T) {
@@ -158,6 +252,8 @@ func TestString(t *testing.T) {
 		Brute:   true,
 		ScanJS:  true,
 		ScanCSS: true,
+		MaxTime: time.Minute,
+		Scope:   ScopeHost,
 	}
 
 	c.validate()
@@ -188,6 +284,14 @@ func TestString(t *testing.T) {
 		t.Error("1 - delay found")
 	}
 
+	if !strings.Contains(v, "max-time: 1m0s") {
+		t.Error("1 - bad max-time")
+	}
+
+	if !strings.Contains(v, "scope: host") {
+		t.Error("1 - bad scope")
+	}
+
 	c = &config{
 		Delay: time.Millisecond * 100,
 	}
@@ -200,6 +304,10 @@ func TestString(t *testing.T) {
 		t.Error("2 - bad brute mode")
 	}
 
+	if strings.Contains(v, "max-") || strings.Contains(v, "scope") {
+		t.Error("2 - budgets found")
+	}
+
 	if !strings.Contains(v, "100") {
 		t.Error("2 - bad delay")
 	}
@@ -211,9 +319,8 @@ func TestProxyAuth(t *testing.T) {
 	const creds = "user:pass"
 
 	var (
-		c       = &config{}
-		opts    = []Option{WithProxyAuth(creds)}
-		headers = []string{proxyAuthHeader(creds)}
+		c    = &config{}
+		opts = []Option{WithProxyAuth(creds)}
 	)
 
 	for _, o := range opts {
@@ -222,7 +329,11 @@ func TestProxyAuth(t *testing.T) {
 
 	c.validate()
 
-	if !reflect.DeepEqual(c.Client.Headers, headers) {
+	if c.Client.ProxyAuth != proxyAuthHeader(creds) {
+		t.Fatalf("bad proxy auth: %v", c.Client.ProxyAuth)
+	}
+
+	if len(c.Client.Headers) != 0 {
 		t.Fatalf("bad extra headers: %v", c.Client.Headers)
 	}
 }
@@ -17,18 +17,19 @@ import (
 	"golang.org/x/net/html/atom"
 
 	"github.com/s0rg/crawley/internal/client"
+	"github.com/s0rg/crawley/internal/graph"
 	"github.com/s0rg/crawley/internal/links"
 	"github.com/s0rg/crawley/internal/robots"
+	"github.com/s0rg/crawley/internal/rules"
 )
 
 type crawlClient interface {
-	Get(context.Context, string) (io.ReadCloser, http.Header, error)
-	Head(context.Context, string) (http.Header, error)
+	Get(context.Context, string) (io.ReadCloser, *client.Response, error)
+	Head(context.Context, string) (*client.Response, error)
 }
 
 const (
 	chMult     = 256
-	chTimeout  = 100 * time.Millisecond
 	doubleDash = "//"
 )
 
@@ -41,10 +42,15 @@ const (
 	TaskCrawl
 	// TaskDone marks result as final - crawling ends here.
 	TaskDone
+	// TaskRetry marks task as failed, it should be crawled once again.
+	TaskRetry
 )
 
 type crawlResult struct {
-	URI  string
+	Result
+	Err  error // request failure, for done tasks
+	Seed int
+
 	Hash uint64
 	Flag taskFlag
 }
@@ -52,11 +58,27 @@ type crawlResult struct {
 // Crawler holds crawling process config and state.
 type Crawler struct {
 	cfg      *config
-	handleCh chan string
-	crawlCh  chan *url.URL
+	handleCh chan *Result
+	crawlCh  chan *crawlTask
 	resultCh chan crawlResult
-	robots   *robots.TXT
+	frontier *frontier
+	seeds    []*seed
 	filter   links.TokenFilter
+	crawl    *rules.Set
+	output   *rules.Set
+	avoid    *rules.Set
+	scope    *scope
+	graph    *graph.Graph
+	budget   *budget
+	session  *sessionWatch
+	stop     context.CancelCauseFunc
+	state    *state
+	failures []Failure
+	broken   []Broken
+	refs     refsMap
+	ext      *externals
+	bare     crawlClient // for checks of foreign hosts
+	anchors  *anchorSet
 	wg       sync.WaitGroup
 }
 
@@ -70,70 +92,240 @@ func New(opts ...Option) (c *Crawler) {
 
 	cfg.validate()
 
-	return &Crawler{
+	c = &Crawler{
 		cfg:    cfg,
-		robots: robots.AllowALL(),
 		filter: prepareFilter(cfg.AlowedTags),
+		scope:  newScope(cfg),
+		crawl:  prepareRules(cfg.CrawlInclude, cfg.CrawlExclude),
+		output: prepareRules(cfg.OutputInclude, cfg.OutputExclude),
+		avoid:  prepareAvoid(cfg),
+		budget: newBudget(cfg, func(error) {}),
+		stop:   func(error) {},
+	}
+
+	if cfg.Graph {
+		c.graph = graph.New()
 	}
+
+	return c
 }
 
 // Run starts crawling process for given base uri.
 func (c *Crawler) Run(uri string, urlcb func(string)) (err error) {
-	var base *url.URL
+	return c.RunContext(context.Background(), uri, urlcb)
+}
+
+// RunContext same as Run, but stops gracefully, when ctx is done.
+func (c *Crawler) RunContext(ctx context.Context, uri string, urlcb func(string)) (err error) {
+	return c.Crawl(ctx, uri, URLHandler(urlcb))
+}
+
+// Crawl starts crawling process for given base uri, passing found urls with their metadata to cb.
+// Urls, that are crawled, are passed after request, so their status and content type are known.
+// It stops gracefully, when ctx is done: no new urls are enqueued, workers are drained and
+// all already found results are passed to cb, same happens, when any of budgets is exceeded,
+// in that case LimitError is returned.
+// If state was restored from checkpoint, crawling continues from it.
+func (c *Crawler) Crawl(ctx context.Context, uri string, cb ResultHandler) (err error) {
+	return c.CrawlSeeds(ctx, []string{uri}, cb)
+}
 
-	if base, err = url.Parse(uri); err != nil {
-		return fmt.Errorf("parse url: %w", err)
+// CrawlSeeds same as Crawl, but starts from many urls at once, every one of them is scoped by itself,
+// while urls dedup and workers are shared. For restored state, seeds returned by Restore should be given.
+func (c *Crawler) CrawlSeeds(ctx context.Context, uris []string, cb ResultHandler) (err error) {
+	if c.seeds, err = parseSeeds(uris); err != nil {
+		return err
 	}
 
-	workers := c.cfg.Client.Workers
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	ctx, cancel := c.withMaxTime(ctx)
+	defer cancel()
+
+	c.reset(stop)
 
-	n := (workers + 1)
-	c.handleCh = make(chan string, n*chMult)
-	c.crawlCh = make(chan *url.URL, n*chMult)
-	c.resultCh = make(chan crawlResult, n*chMult)
+	st, err := c.takeState()
+	if err != nil {
+		return err
+	}
 
-	defer c.close()
+	ccfg := c.cfg.Client
+	ccfg.AuthHosts = authHosts(c.seeds, ccfg.AuthHosts)
+
+	web := client.New(&ccfg)
+	if err = web.Login(ctx); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
 
-	seen := make(set.Unordered[uint64])
-	seen.Add(urlhash(uri))
+	c.state = nil
 
-	web := client.New(&c.cfg.Client)
-	c.initRobots(base, web)
+	w := len(st.pending) + c.start(ctx, web, st, cb)
+
+	c.emitKept(st)
+
+	err = c.loop(ctx, st, w)
+
+	if c.ext != nil {
+		c.ext.Wait()
+	}
+
+	c.close()
+
+	if c.cfg.Checkpoint != "" {
+		c.saveCheckpoint(st)
+	}
+
+	return err
+}
+
+// withMaxTime limits ctx with duration budget, if any.
+func (c *Crawler) withMaxTime(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.cfg.MaxTime <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, c.cfg.MaxTime, LimitError{
+		Limit: limitDuration,
+		Max:   c.cfg.MaxTime.String(),
+	})
+}
+
+// reset prepares crawler for new crawl, dropping results of previous one.
+func (c *Crawler) reset(stop context.CancelCauseFunc) {
+	c.budget = newBudget(c.cfg, stop)
+	c.failures = nil
+	c.broken, c.refs, c.ext, c.anchors, c.bare = nil, nil, nil, nil, nil
+
+	if c.cfg.CheckAnchors {
+		c.anchors = newAnchorSet()
+	}
+
+	if c.cfg.Check || c.cfg.CheckExternal {
+		c.refs = make(refsMap)
+	}
+
+	if c.cfg.Check {
+		c.bare = newBareClient(c.cfg, c.cfg.Client.Workers, c.cfg.Client.HostConns, c.cfg.Client.HostRate)
+	}
+
+	c.session = &sessionWatch{}
+	c.stop = stop
+}
+
+// takeState returns restored state (if any), or new one, for seeds.
+func (c *Crawler) takeState() (st *state, err error) {
+	if st = c.state; st == nil {
+		return newState(seedURLs(c.seeds)), nil
+	}
+
+	for _, t := range st.pending {
+		if t.Seed >= len(c.seeds) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSeed, t.URI)
+		}
+	}
+
+	return st, nil
+}
+
+// start starts workers, results handler and externals checkers, and queues pending tasks,
+// returns count of started robots.txt tasks.
+func (c *Crawler) start(
+	ctx context.Context,
+	web crawlClient,
+	st *state,
+	cb ResultHandler,
+) (n int) {
+	workers := c.cfg.Client.Workers
+	size := (workers + 1) * chMult
+
+	c.handleCh = make(chan *Result, size)
+	c.resultCh = make(chan crawlResult, size)
+	c.frontier = newFrontier(c.cfg.FrontierSize, c.tasksLost)
+	c.crawlCh = c.frontier.out
+
+	n = c.initSeeds(ctx, web)
 
 	for i := 0; i < workers; i++ {
-		go c.worker(web)
+		go c.worker(ctx, web)
 	}
 
 	c.wg.Add(workers)
 
 	go func() {
-		for s := range c.handleCh {
-			urlcb(s)
+		for r := range c.handleCh {
+			cb(r)
 		}
 
 		c.wg.Done()
 	}()
 
-	c.crawlCh <- base
+	for _, t := range st.Frontier() {
+		c.frontier.Push(t)
+	}
 
-	var t crawlResult
+	c.startExternals(ctx)
 
-	for w := 1; w > 0; {
-		t = <-c.resultCh
+	return n
+}
 
-		switch {
-		case t.Flag == TaskDone:
-			w--
-		case seen.Add(t.Hash):
-			if t.Flag == TaskCrawl && c.tryEnqueue(base, &t) {
-				w++
-			}
+// Restore loads crawling config and state from checkpoint file, next call to Run / RunContext
+// will continue crawling from it, without reporting already seen urls. Returns urls, crawl was started from.
+// Links checks cannot be resumed, as their results are not saved.
+func (c *Crawler) Restore(name string) (seeds []string, err error) {
+	cp, err := loadCheckpoint(name)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
 
-			c.tryHandle(t.URI)
-		}
+	if cp.Config.Check || cp.Config.CheckExternal || cp.Config.CheckAnchors {
+		return nil, fmt.Errorf("checkpoint: %w", ErrCheckResume)
+	}
+
+	if c.state, err = cp.State(); err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
+
+	cp.Config.Checkpoint = c.cfg.Checkpoint
+	cp.Config.CheckpointEvery = c.cfg.CheckpointEvery
+	cp.Config.Graph = c.cfg.Graph
+	cp.Config.Client.Jar = c.cfg.Client.Jar
+	cp.Config.Client.Login = c.cfg.Client.Login
+	cp.Config.Client.Auth = c.cfg.Client.Auth
+	cp.Config.Client.OAuth = c.cfg.Client.OAuth
+	cp.Config.Client.AuthHosts = c.cfg.Client.AuthHosts
+	cp.Config.Client.Cert = c.cfg.Client.Cert
+	cp.Config.Client.RootCAs = c.cfg.Client.RootCAs
+
+	// fresh credentials, to continue crawl, paused on session loss
+	if len(c.cfg.Client.Cookies) > 0 {
+		cp.Config.Client.Cookies = c.cfg.Client.Cookies
+	}
+
+	if len(c.cfg.Client.Headers) > 0 {
+		cp.Config.Client.Headers = c.cfg.Client.Headers
 	}
 
-	return nil
+	cp.Config.validate()
+
+	c.cfg = &cp.Config
+	c.filter = prepareFilter(c.cfg.AlowedTags)
+	c.scope = newScope(c.cfg)
+	c.crawl = prepareRules(c.cfg.CrawlInclude, c.cfg.CrawlExclude)
+	c.output = prepareRules(c.cfg.OutputInclude, c.cfg.OutputExclude)
+	c.avoid = prepareAvoid(c.cfg)
+
+	return cp.Seeds, nil
+}
+
+// Failures returns urls, that could not be crawled (even after retries), during last crawl.
+func (c *Crawler) Failures() []Failure {
+	return c.failures
+}
+
+// Graph returns links graph, recorded during crawl, or nil if recording is disabled.
+func (c *Crawler) Graph() *graph.Graph {
+	return c.graph
 }
 
 // DumpConfig returns internal config representation.
@@ -141,12 +333,16 @@ func (c *Crawler) DumpConfig() string {
 	return c.cfg.String()
 }
 
-func (c *Crawler) tryHandle(u string) {
+// tryHandle emits result, if it passes output filters, returns false only if it was refused by urls budget,
+// so it should be kept to be emitted after resume.
+func (c *Crawler) tryHandle(r *Result) (ok bool) {
 	show := true
 
+	u := r.URL
+
 	idx := strings.LastIndexByte(u, '/')
 	if idx == -1 {
-		return
+		return true
 	}
 
 	switch c.cfg.Dirs {
@@ -156,45 +352,245 @@ func (c *Crawler) tryHandle(u string) {
 		show = !isResorce(u[idx:])
 	}
 
-	if !show {
+	if !show || !c.output.Allow(u) {
+		return true
+	}
+
+	if !c.budget.TakeURL() {
+		return false
+	}
+
+	c.handleCh <- r
+
+	return true
+}
+
+// emitKept emits results, kept from previous run, ones refused once again are kept for the next one.
+func (c *Crawler) emitKept(st *state) {
+	kept := st.results
+	st.results = nil
+
+	for _, r := range kept {
+		c.emit(st, r)
+	}
+}
+
+// emit emits result, keeping it in state, if it was refused by urls budget.
+func (c *Crawler) emit(st *state, r *Result) {
+	if !c.tryHandle(r) {
+		st.results = append(st.results, r)
+	}
+}
+
+func (c *Crawler) loop(
+	ctx context.Context,
+	st *state,
+	w int,
+) (err error) {
+	var (
+		t    crawlResult
+		tick <-chan time.Time
+	)
+
+	if c.cfg.Checkpoint != "" && c.cfg.CheckpointEvery > 0 {
+		ticker := time.NewTicker(c.cfg.CheckpointEvery)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for w > 0 {
+		select {
+		case <-tick:
+			c.saveCheckpoint(st)
+
+			continue
+		case t = <-c.resultCh:
+		}
+
+		// workers are context-aware, so there always will be results to check
+		if err == nil && ctx.Err() != nil {
+			// stop enqueuing, but keep draining results, until all workers are done
+			err = stopError(ctx)
+		}
+
+		c.record(&t)
+
+		switch {
+		case t.Flag == TaskRetry && err == nil && st.pending[t.URL] != nil:
+			c.retry(ctx, st.pending[t.URL])
+		case t.Flag == TaskDone, t.Flag == TaskRetry:
+			c.taskDone(st, &t, err != nil)
+
+			w--
+		case st.seen.Add(t.Hash):
+			if c.found(st, &t, err != nil) {
+				w++
+			}
+		}
+	}
+
+	return err
+}
+
+// record records found link: its source page and graph edge.
+func (c *Crawler) record(r *crawlResult) {
+	if r.Flag != TaskDefault && r.Flag != TaskCrawl {
+		return
+	}
+
+	c.refs.Add(r.URL, r.Source)
+
+	if c.graph != nil {
+		c.graph.AddEdge(r.Source, r.URL)
+	}
+}
+
+func (c *Crawler) taskDone(st *state, r *crawlResult, canceled bool) {
+	task, ok := st.pending[r.URL]
+	if !ok {
+		return
+	}
+
+	if res := task.Result; res != nil {
+		res.Status, res.Reason, res.ContentType, res.Redirects = r.Status, r.Reason, r.ContentType, r.Redirects
+
+		// refused result is kept along with task, while it is pending
+		if c.tryHandle(res) {
+			task.Result = nil
+		}
+	}
+
+	// after cancellation tasks are drained, not crawled - keep them for resume
+	if canceled {
 		return
 	}
 
-	t := time.NewTimer(chTimeout)
-	defer t.Stop()
+	delete(st.pending, r.URL)
+
+	if task.Result != nil {
+		st.results = append(st.results, task.Result)
+	}
+
+	if transient(r.Status, r.Err) {
+		c.failures = append(c.failures, Failure{
+			URL:    r.URL,
+			Error:  r.Err.Error(),
+			Status: r.Status,
+			Tries:  task.Tries + 1,
+		})
+	}
 
-	select {
-	case c.handleCh <- u:
-	case <-t.C:
+	if c.cfg.Check && isBroken(r.Status, r.Err) {
+		c.addBroken(r)
 	}
 }
 
-func (c *Crawler) tryEnqueue(base *url.URL, r *crawlResult) (yes bool) {
-	u, err := url.Parse(r.URI)
+// nextTask creates task for found url: to crawl it, if possible, or (in check mode) just to check it.
+func (c *Crawler) nextTask(r *crawlResult) (t *crawlTask, yes bool) {
+	if r.Flag == TaskCrawl {
+		if t, yes = c.tryEnqueue(r); yes {
+			return t, yes
+		}
+	}
+
+	if c.ext != nil {
+		if t, yes = c.tryExternal(r); yes {
+			// external links are checked by their own pool
+			c.ext.Push(t)
+
+			return nil, false
+		}
+	}
+
+	if c.cfg.Check {
+		return c.tryCheck(r)
+	}
+
+	return nil, false
+}
+
+// found handles newly seen url: enqueues task for it (its result will be emitted, when task is done),
+// or emits result at once. After stop tasks are only kept as pending, so they are saved to checkpoint.
+func (c *Crawler) found(st *state, r *crawlResult, stopped bool) (enqueued bool) {
+	task, ok := c.nextTask(r)
+	if ok && st.Add(task) {
+		if !stopped {
+			c.frontier.Push(task)
+
+			return true
+		}
+
+		// result is emitted right now, so it wont be emitted once again after resume,
+		// unless it is refused - then it is emitted, when task is done
+		if c.tryHandle(task.Result) {
+			task.Result = nil
+		}
+
+		return false
+	}
+
+	res := r.Result
+	c.emit(st, &res)
+
+	return false
+}
+
+func (c *Crawler) tryEnqueue(r *crawlResult) (t *crawlTask, yes bool) {
+	u, err := url.Parse(r.URL)
 	if err != nil {
 		return
 	}
 
-	if !canCrawl(base, u, c.cfg.Depth, c.cfg.Subdomains) ||
-		c.robots.Forbidden(u.Path) ||
+	s := c.seeds[r.Seed]
+
+	if !canHop(r.Depth, c.cfg.Hops) ||
+		!canCrawl(s.URL, u, c.cfg.Depth, c.scope) ||
+		s.robots.Forbidden(u.Path) ||
 		(c.cfg.Dirs == DirsOnly && isResorce(u.Path)) {
 		return
 	}
 
-	t := time.NewTimer(chTimeout)
-	defer t.Stop()
+	res := r.Result
 
-	select {
-	case c.crawlCh <- u:
-		return true
-	case <-t.C:
+	t = newTask(u)
+	t.Depth = r.Depth
+	t.Seed = r.Seed
+	t.Result = &res
+
+	return t, true
+}
+
+// tasksLost marks tasks, that frontier failed to deliver, as done, so crawl wont stall on them,
+// they still are pending, so they are saved to checkpoint.
+func (c *Crawler) tasksLost(n int) {
+	// called from frontier, which can be blocked by loop, so results are sent asynchronously
+	go func() {
+		for range n {
+			c.resultCh <- crawlResult{Flag: TaskDone}
+		}
+	}()
+}
+
+func (c *Crawler) saveCheckpoint(st *state) {
+	cp := checkpoint{
+		Config: *c.cfg,
+		Seeds:  make([]string, len(c.seeds)),
 	}
 
-	return false
+	for i, s := range c.seeds {
+		cp.Seeds[i] = s.URL.String()
+	}
+
+	cp.SetState(st)
+
+	if err := cp.Save(c.cfg.Checkpoint); err != nil {
+		log.Println("[-] checkpoint:", err)
+	}
 }
 
 func (c *Crawler) close() {
-	close(c.crawlCh)
+	c.frontier.Close()
 	c.wg.Wait() // wait for crawlers
 
 	c.wg.Add(1) // for handler`s Done()
@@ -204,26 +600,64 @@ func (c *Crawler) close() {
 	close(c.resultCh)
 }
 
-func (c *Crawler) initRobots(host *url.URL, web crawlClient) {
+// initSeeds loads robots.txt rules for every seed host (once per host) and starts emitting
+// their links, returns count of started tasks.
+func (c *Crawler) initSeeds(ctx context.Context, web crawlClient) (n int) {
 	if c.cfg.Robots == RobotsIgnore {
-		return
+		return 0
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Client.Timeout)
-	defer cancel()
+	hosts := make(map[string]*robots.TXT)
 
-	body, _, err := web.Get(ctx, robots.URL(host))
-	if err != nil {
-		var herr client.HTTPError
+	for i, s := range c.seeds {
+		key := s.URL.Scheme + doubleDash + s.URL.Host
 
-		if !errors.As(err, &herr) {
-			log.Println("[-] GET /robots.txt:", err)
+		if rbt, ok := hosts[key]; ok {
+			s.robots = rbt
+
+			continue
+		}
+
+		rbt, ok := c.initRobots(ctx, s.URL, web)
+		s.robots, hosts[key] = rbt, rbt
+
+		if !ok {
+			continue
+		}
+
+		// robots.txt links are emitted as a separate task, to not block on results
+		n++
+
+		go func() {
+			c.crawlRobots(i)
+			c.resultCh <- crawlResult{Flag: TaskDone}
+		}()
+	}
 
-			return
+	return n
+}
+
+func (c *Crawler) initRobots(
+	parent context.Context,
+	host *url.URL,
+	web crawlClient,
+) (rbt *robots.TXT, ok bool) {
+	rbt = robots.AllowALL()
+
+	ctx, cancel := context.WithTimeout(parent, c.cfg.Client.Timeout)
+	defer cancel()
+
+	body, resp, err := web.Get(ctx, robots.URL(host))
+	if err != nil {
+		if body != nil {
+			client.Discard(body)
 		}
 
-		if herr.Code() == http.StatusInternalServerError {
-			c.robots = robots.DenyALL()
+		switch {
+		case resp.Code == 0:
+			log.Println("[-] GET /robots.txt:", err)
+		case resp.Code >= http.StatusInternalServerError:
+			rbt = robots.DenyALL()
 		}
 
 		return
@@ -231,33 +665,35 @@ func (c *Crawler) initRobots(host *url.URL, web crawlClient) {
 
 	defer body.Close()
 
-	rbt, err := robots.FromReader(c.cfg.Client.UserAgent, body)
+	tmp, err := robots.FromReader(c.cfg.Client.UserAgent, body)
 	if err != nil {
 		log.Println("[-] parse robots.txt:", err)
 
 		return
 	}
 
-	c.robots = rbt
-
-	c.crawlRobots(host)
+	return tmp, true
 }
 
-func (c *Crawler) crawlRobots(host *url.URL) {
-	base := *host
+func (c *Crawler) crawlRobots(idx int) {
+	s := c.seeds[idx]
+
+	base := *s.URL
 	base.Fragment = ""
 	base.RawQuery = ""
 
-	for _, u := range c.robots.Links() {
+	src := &crawlTask{URI: robots.URL(s.URL), Seed: idx}
+
+	for _, u := range s.robots.Links() {
 		t := base
 		t.Path = u
 
-		c.linkHandler(atom.A, t.String())
+		c.linkHandler(src, atom.A, t.String(), LinkRobots)
 	}
 
-	for _, u := range c.robots.Sitemaps() {
+	for _, u := range s.robots.Sitemaps() {
 		if _, e := url.Parse(u); e == nil {
-			c.crawlHandler(u)
+			c.linkHandler(src, atom.A, u, LinkSitemap)
 		}
 	}
 }
@@ -272,55 +708,103 @@ func (c *Crawler) isIgnored(v string) (yes bool) {
 	})
 }
 
-func (c *Crawler) linkHandler(a atom.Atom, s string) {
+func (c *Crawler) linkHandler(
+	src *crawlTask,
+	a atom.Atom,
+	s string,
+	typ LinkType,
+) {
 	r := crawlResult{
-		URI:  s,
+		Result: Result{
+			URL:    s,
+			Source: src.URI,
+			Tag:    a,
+			Depth:  src.Depth + 1,
+			Type:   typ,
+		},
 		Hash: urlhash(s),
+		Seed: src.Seed,
 	}
 
 	fetch := (a == atom.A || a == atom.Iframe) ||
 		(c.cfg.ScanJS && a == atom.Script) ||
 		(c.cfg.ScanCSS && a == atom.Link)
 
-	if fetch && !c.isIgnored(s) {
+	if fetch && !c.isIgnored(s) && c.avoid.Allow(s) && c.crawl.Allow(s) {
 		r.Flag = TaskCrawl
 	}
 
-	t := time.NewTimer(chTimeout)
-	defer t.Stop()
-
-	select {
-	case c.resultCh <- r:
-	case <-t.C:
-	}
+	c.resultCh <- r
 }
 
-func (c *Crawler) staticHandler(s string) {
-	c.linkHandler(atom.Link, s)
+func (c *Crawler) staticHandler(src *crawlTask, s string) {
+	c.linkHandler(src, atom.Link, s, LinkStatic)
 }
 
-func (c *Crawler) crawlHandler(s string) {
-	c.linkHandler(atom.A, s)
+func (c *Crawler) crawlHandler(src *crawlTask, s string) {
+	c.linkHandler(src, atom.A, s, linkType(atom.A, s))
 }
 
 func (c *Crawler) process(
 	ctx context.Context,
 	web crawlClient,
-	base *url.URL,
-	uri string,
-) {
-	body, hdrs, err := web.Get(ctx, uri)
-	if err != nil {
-		var herr client.HTTPError
+	task *crawlTask,
+	hops *[]Redirect,
+) (resp *client.Response, err error) {
+	// only fetched pages are counted, not HEAD requests
+	if !c.budget.TakePage() {
+		return &client.Response{}, context.Cause(ctx)
+	}
 
-		// ignore any http errors, just parse body (if any)
-		if !errors.As(err, &herr) {
-			log.Printf("[-] GET %s: %v", uri, err)
+	rc, resp, err := web.Get(ctx, task.URI)
+	if resp.Code == 0 {
+		c.requestFailed(http.MethodGet, task.URI, resp.Code, err)
 
-			return
-		}
+		return resp, err
+	}
+
+	status, content := resp.Code, resp.Header.Get(contentType)
+
+	// links are resolved against page, request was redirected to
+	base, uri := task.URL, task.URI
+	if resp.URL != nil {
+		base, uri = resp.URL, resp.URL.String()
+	}
+
+	c.redirectStopped(task, hops, resp)
+
+	if c.canRetry(task, status, err) {
+		// do not parse error page, as there will be another try
+		client.Discard(rc)
+
+		return resp, err
+	}
+
+	// ignore any other http errors, just parse body (if any)
+
+	body := c.budget.Body(rc)
+
+	c.extract(task, body, base, uri, resp)
+
+	client.Discard(body)
+
+	if c.cfg.Client.SessionCheck && status == http.StatusOK && isHTML(content) && !c.session.Check(body.size) {
+		c.checkSession(uri, fmt.Errorf("%w: same responses of %d bytes", client.ErrSessionLost, body.size))
 	}
 
+	return resp, err
+}
+
+// extract parses body, according to its content type, and emits found links.
+func (c *Crawler) extract(
+	task *crawlTask,
+	body io.Reader,
+	base *url.URL,
+	uri string,
+	resp *client.Response,
+) {
+	content := resp.Header.Get(contentType)
+
 	handleStatic := func(s string) {
 		var ok bool
 
@@ -334,67 +818,155 @@ func (c *Crawler) process(
 		}
 
 		if ok {
-			c.staticHandler(s)
+			c.staticHandler(task, s)
 		}
 	}
 
-	content := hdrs.Get(contentType)
-
 	switch {
 	case isHTML(content):
-		links.ExtractHTML(body, base, links.HTMLParams{
-			Brute:        c.cfg.Brute,
-			ScanJS:       c.cfg.ScanJS,
-			ScanCSS:      c.cfg.ScanCSS,
-			Filter:       c.filter,
-			HandleHTML:   c.linkHandler,
-			HandleStatic: handleStatic,
-		})
+		c.extractHTML(task, body, base, uri, handleStatic, resp.Success())
 	case isSitemap(uri):
-		links.ExtractSitemap(body, base, c.crawlHandler)
+		links.ExtractSitemap(body, base, func(s string) {
+			c.crawlHandler(task, s)
+		})
 	case c.cfg.ScanJS && isJS(content, uri):
 		links.ExtractJS(body, handleStatic)
 	case c.cfg.ScanCSS && isCSS(content, uri):
 		links.ExtractCSS(body, handleStatic)
 	}
+}
 
-	client.Discard(body)
+// extractHTML parses html page, collecting its anchors, if they are checked.
+func (c *Crawler) extractHTML(
+	task *crawlTask,
+	body io.Reader,
+	base *url.URL,
+	uri string,
+	handleStatic func(string),
+	success bool,
+) {
+	params := links.HTMLParams{
+		Brute:   c.cfg.Brute,
+		ScanJS:  c.cfg.ScanJS,
+		ScanCSS: c.cfg.ScanCSS,
+		Filter:  c.filter,
+		HandleHTML: func(a atom.Atom, s string) {
+			c.linkHandler(task, a, s, linkType(a, s))
+		},
+		HandleStatic: handleStatic,
+	}
+
+	var names set.Set[string]
+
+	if c.anchors != nil {
+		names = make(set.Unordered[string])
+		params.HandleAnchor = func(s string) { names.Add(s) }
+		params.HandleFragment = func(u, fragment string) { c.anchors.AddRef(u, fragment, uri) }
+	}
+
+	links.ExtractHTML(body, base, params)
+
+	if names != nil && success {
+		c.anchors.AddPage(names, task.URI, uri)
+	}
+}
+
+// canRetry reports, if failed task can be tried once again.
+func (c *Crawler) canRetry(task *crawlTask, status int, err error) (yes bool) {
+	return task.Tries < c.cfg.Retries && transient(status, err)
+}
+
+// requestFailed logs request error, transient ones (and all of them, in check mode, as broken links)
+// are not logged, as they are reported after crawl.
+func (c *Crawler) requestFailed(method, uri string, status int, err error) {
+	if !c.cfg.Check && !transient(status, err) {
+		log.Printf("[-] %s %s: %v", method, uri, err)
+	}
+
+	c.checkSession(uri, err)
+}
+
+// checkSession pauses crawl, if err signals session loss.
+func (c *Crawler) checkSession(uri string, err error) {
+	if errors.Is(err, client.ErrSessionLost) {
+		c.stop(SessionError{URL: uri, Err: err})
+	}
 }
 
-func (c *Crawler) worker(web crawlClient) {
+func (c *Crawler) worker(parent context.Context, web crawlClient) {
 	defer c.wg.Done()
 
-	for uri := range c.crawlCh {
-		if c.cfg.Delay > 0 {
-			time.Sleep(c.cfg.Delay)
-		}
+	for task := range c.crawlCh {
+		r := crawlResult{Flag: TaskDone}
+		r.URL = task.URI
 
-		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Client.Timeout)
-		us := uri.String()
+		// on cancel - just mark task as done, to drain queue
+		if sleepContext(parent, c.cfg.Delay) {
+			var resp *client.Response
 
-		var canProcess bool
+			ctx, cancel := context.WithTimeout(parent, c.cfg.Client.Timeout)
 
-		if c.cfg.NoHEAD {
-			canProcess = canParse(uri.Path)
-		} else {
-			if hdrs, err := web.Head(ctx, us); err != nil {
-				log.Printf("[-] HEAD %s: %v", us, err)
+			if task.Check {
+				resp, r.Err = c.check(ctx, web, task, &r.Redirects)
 			} else {
-				ct := hdrs.Get(contentType)
+				ctx = client.WithRedirectFunc(ctx, c.trackRedirects(task, &r.Redirects))
+				resp, r.Err = c.visit(ctx, web, task, &r.Redirects)
+			}
+
+			r.Status, r.Reason, r.ContentType = resp.Code, resp.Reason, resp.Header.Get(contentType)
 
-				canProcess = isHTML(ct) ||
-					isSitemap(us) ||
-					(c.cfg.ScanJS && isJS(ct, us)) ||
-					(c.cfg.ScanCSS && isCSS(ct, us))
+			cancel()
+
+			if parent.Err() == nil && c.canRetry(task, r.Status, r.Err) {
+				r.Flag = TaskRetry
 			}
 		}
 
-		if canProcess {
-			c.process(ctx, web, uri, us)
+		c.resultCh <- r
+	}
+}
+
+func (c *Crawler) visit(
+	ctx context.Context,
+	web crawlClient,
+	task *crawlTask,
+	hops *[]Redirect,
+) (resp *client.Response, err error) {
+	var (
+		canProcess bool
+		uri, us    = task.URL, task.URI
+	)
+
+	resp = &client.Response{}
+
+	if c.cfg.NoHEAD {
+		canProcess = canParse(uri.Path)
+	} else {
+		resp, err = web.Head(ctx, us)
+		content := resp.Header.Get(contentType)
+
+		switch {
+		case resp.Code == http.StatusMethodNotAllowed:
+			// HEAD is not supported, so there is no way to know content type, without GET
+			canProcess = true
+		case err != nil && !isRedirect(resp.Code):
+			c.requestFailed(http.MethodHead, us, resp.Code, err)
+		default:
+			canProcess = err == nil && (isHTML(content) ||
+				isSitemap(us) ||
+				(c.cfg.ScanJS && isJS(content, us)) ||
+				(c.cfg.ScanCSS && isCSS(content, us)))
 		}
 
-		cancel()
+		c.redirectStopped(task, hops, resp)
+	}
+
+	if canProcess {
+		// same chain is recorded once again by GET
+		*hops = nil
 
-		c.resultCh <- crawlResult{Flag: TaskDone}
+		return c.process(ctx, web, task, hops)
 	}
+
+	return resp, err
 }
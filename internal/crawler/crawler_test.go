@@ -6,15 +6,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/s0rg/set"
 	"golang.org/x/net/html/atom"
+
+	"github.com/s0rg/crawley/internal/client"
 )
 
 const robotsEP = "/robots.txt"
@@ -34,15 +42,23 @@ type testClient struct {
 func (tc *testClient) Get(
 	_ context.Context,
 	_ string,
-) (body io.ReadCloser, h http.Header, err error) {
-	return tc.bodyIO, nil, tc.err
+) (body io.ReadCloser, resp *client.Response, err error) {
+	return tc.bodyIO, tc.response(), tc.err
 }
 
 func (tc *testClient) Head(
 	_ context.Context,
 	_ string,
-) (h http.Header, err error) {
-	return
+) (resp *client.Response, err error) {
+	return tc.response(), nil
+}
+
+func (tc *testClient) response() (resp *client.Response) {
+	if tc.err != nil {
+		return &client.Response{}
+	}
+
+	return &client.Response{Code: http.StatusOK}
 }
 
 type errReader struct {
@@ -110,15 +126,16 @@ func TestCrawlerOK(t *testing.T) {
 		t.Error("results: less than expected")
 	}
 
-	if results[1] != "http://other.host/image.bmp" {
+	if results[0] != "http://other.host/image.bmp" {
 		t.Error("results: no image")
 	}
 
-	if !strings.HasSuffix(results[0], "/result") {
-		t.Error("results: bad item at 0")
+	if !strings.HasSuffix(results[1], "/deep/path") {
+		t.Error("results: bad item at 1")
 	}
 
-	if !strings.HasSuffix(results[2], "/deep/path") {
+	// crawled urls are reported after request
+	if !strings.HasSuffix(results[2], "/result") {
 		t.Error("results: bad item at 2")
 	}
 }
@@ -327,7 +344,7 @@ func TestCrawlerRobotsErr500(t *testing.T) {
 		t.Error("unexpected len")
 	}
 
-	if !c.robots.Forbidden("/some") {
+	if !c.seeds[0].robots.Forbidden("/some") {
 		t.Error("not forbidden")
 	}
 }
@@ -366,7 +383,7 @@ func TestCrawlerRobotsErr400(t *testing.T) {
 		t.Error("unexpected len")
 	}
 
-	if c.robots.Forbidden("/some") {
+	if c.seeds[0].robots.Forbidden("/some") {
 		t.Error("forbidden")
 	}
 }
@@ -383,9 +400,9 @@ func TestCrawlerRobotsRequestErr(t *testing.T) {
 		)
 	)
 
-	c.initRobots(base, &tc)
+	rbt, _ := c.initRobots(t.Context(), base, &tc)
 
-	if c.robots.Forbidden("/some") {
+	if rbt.Forbidden("/some") {
 		t.Error("forbidden")
 	}
 }
@@ -402,9 +419,9 @@ func TestCrawlerRobotsBodytErr(t *testing.T) {
 		)
 	)
 
-	c.initRobots(base, &tc)
+	rbt, _ := c.initRobots(t.Context(), base, &tc)
 
-	if c.robots.Forbidden("/some") {
+	if rbt.Forbidden("/some") {
 		t.Error("forbidden")
 	}
 }
@@ -555,16 +572,16 @@ func TestCrawlerGetNonHTTPErr(t *testing.T) {
 		c       = New(WithoutHeads(true))
 	)
 
-	c.crawlCh = make(chan *url.URL, 1)
+	c.crawlCh = make(chan *crawlTask, 1)
 	c.resultCh = make(chan crawlResult, 1)
 
-	c.crawlCh <- base
+	c.crawlCh <- newTask(base)
 
 	close(c.crawlCh)
 
 	c.wg.Add(1)
 
-	go c.worker(&tc)
+	go c.worker(t.Context(), &tc)
 
 	c.wg.Wait()
 
@@ -589,9 +606,9 @@ func TestCrawlerBadEmit(t *testing.T) {
 	t.Parallel()
 
 	c := New(WithoutHeads(true))
-	c.handleCh = make(chan string, 1)
+	c.handleCh = make(chan *Result, 1)
 
-	c.tryHandle("no-slash")
+	c.tryHandle(&Result{URL: "no-slash"})
 
 	close(c.handleCh)
 
@@ -610,9 +627,8 @@ func TestCrawlerBad(t *testing.T) {
 	t.Parallel()
 
 	c := New(WithoutHeads(true))
-	base, _ := url.Parse("http://test/")
 
-	if c.tryEnqueue(base, &crawlResult{URI: "%"}) {
+	if _, ok := c.tryEnqueue(&crawlResult{Result: Result{URL: "%"}}); ok {
 		t.Error("can crawl bad uri")
 	}
 }
@@ -966,21 +982,47 @@ func TestCrawlerScanCSSURLNoCT(t *testing.T) {
 	}
 }
 
-func TestCrawlerOverflow(t *testing.T) {
+func TestCrawlerNoOverflow(t *testing.T) {
 	t.Parallel()
 
+	const count = 100
+
+	var body strings.Builder
+
+	body.WriteString("<html>")
+
+	for i := range count {
+		fmt.Fprintf(&body, `<a href="/page%d">p</a>`, i)
+	}
+
+	body.WriteString("</html>")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		if r.RequestURI == "/" {
+			_, _ = io.WriteString(w, body.String())
+		}
+	}))
+
+	defer ts.Close()
+
 	c := New(
 		WithoutHeads(true),
 		WithMaxCrawlDepth(1),
+		WithFrontierSize(3),
 	)
-	base, _ := url.Parse("http://test/")
-	res := crawlResult{URI: "http://test/foo"}
 
-	c.tryHandle("/")
-	c.linkHandler(atom.A, "")
+	res := make(set.Unordered[string])
+
+	if err := c.Run(ts.URL, func(s string) {
+		res.Add(s)
+	}); err != nil {
+		t.Fatal("run:", err)
+	}
 
-	if c.tryEnqueue(base, &res) {
-		t.Error("no overflow")
+	if res.Len() != count {
+		t.Error("unexpected results count:", res.Len())
 	}
 }
 
@@ -1020,3 +1062,1428 @@ body {background: url("test.png");}
 		t.Fail()
 	}
 }
+
+func TestCrawlerRunContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="/a">a</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+	)
+
+	err := c.RunContext(ctx, ts.URL, func(_ string) {})
+	if err == nil {
+		t.Fatal("no error")
+	}
+
+	var cerr CancelError
+
+	if !errors.As(err, &cerr) {
+		t.Error("not a cancel error")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Error("not a context error")
+	}
+
+	if cerr.Error() == "" {
+		t.Error("empty message")
+	}
+}
+
+func TestCrawlerRunContextDrain(t *testing.T) {
+	t.Parallel()
+
+	const body = `<html><a href="/a">a</a><a href="/b">b</a><img src="/c.png"/></html>`
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		if r.RequestURI != "/" {
+			cancel()
+		}
+
+		_, _ = io.WriteString(w, body)
+	}))
+
+	defer ts.Close()
+
+	res := make(set.Unordered[string])
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(1),
+	)
+
+	err := c.RunContext(ctx, ts.URL, func(s string) {
+		res.Add(s)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(res) != 3 {
+		t.Error("unexpected results count:", len(res))
+	}
+}
+
+func TestCrawlerResults(t *testing.T) {
+	t.Parallel()
+
+	const body = `<html><a href="/page">page</a><img src="/pic.png"/><a href="/missing">404</a></html>`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Add(contentType, contentHTML)
+			_, _ = io.WriteString(w, body)
+		}
+	}))
+
+	defer ts.Close()
+
+	res := make(map[string]*Result)
+
+	c := New(WithMaxCrawlDepth(1))
+
+	if err := c.Crawl(t.Context(), ts.URL, func(r *Result) {
+		res[r.URL] = r
+	}); err != nil {
+		t.Fatal("crawl:", err)
+	}
+
+	if len(res) != 3 {
+		t.Fatal("unexpected results count:", len(res))
+	}
+
+	page, ok := res[ts.URL+"/page"]
+	if !ok {
+		t.Fatal("no page")
+	}
+
+	if page.Source != ts.URL || page.Tag != atom.A || page.Depth != 1 || page.Type != LinkPage {
+		t.Error("page: unexpected metadata:", page)
+	}
+
+	if page.Status != http.StatusOK || !isHTML(page.ContentType) {
+		t.Error("page: unexpected status:", page.Status, page.ContentType)
+	}
+
+	pic, ok := res[ts.URL+"/pic.png"]
+	if !ok {
+		t.Fatal("no pic")
+	}
+
+	if pic.Tag != atom.Img || pic.Type != LinkStatic || pic.Status != 0 || pic.ContentType != "" {
+		t.Error("pic: unexpected metadata:", pic)
+	}
+
+	missing, ok := res[ts.URL+"/missing"]
+	if !ok {
+		t.Fatal("no missing")
+	}
+
+	if missing.Status != http.StatusNotFound {
+		t.Error("missing: unexpected status:", missing.Status)
+	}
+}
+
+func TestCrawlerGraph(t *testing.T) {
+	t.Parallel()
+
+	const (
+		body  = `<html><a href="/a">a</a><a href="/b">b</a></html>`
+		bodyA = `<html><a href="/">root</a><a href="/b">b</a></html>`
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.RequestURI {
+		case "/a":
+			_, _ = io.WriteString(w, bodyA)
+		case "/":
+			_, _ = io.WriteString(w, body)
+		}
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(1),
+		WithoutHeads(true),
+	)
+
+	if c.Graph() != nil {
+		t.Fatal("graph is not nil")
+	}
+
+	c = New(
+		WithMaxCrawlDepth(1),
+		WithoutHeads(true),
+		WithLinkGraph(true),
+	)
+
+	if err := c.Run(ts.URL+"/", func(_ string) {}); err != nil {
+		t.Fatal("run:", err)
+	}
+
+	g := c.Graph()
+
+	// root -> a, root -> b, a -> root, a -> b
+	if g.Nodes() != 3 || g.Edges() != 4 {
+		t.Errorf("unexpected graph: %d nodes %d edges", g.Nodes(), g.Edges())
+	}
+}
+
+func TestCrawlerMaxPages(t *testing.T) {
+	t.Parallel()
+
+	var pages atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		pages.Add(1)
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="a/">a</a><a href="b/">b</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(1),
+		WithMaxPages(3),
+	)
+
+	err := c.Run(ts.URL+"/", func(_ string) {})
+
+	var lerr LimitError
+
+	if !errors.As(err, &lerr) || lerr.Limit != limitPages {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if n := pages.Load(); n != 3 {
+		t.Error("unexpected pages count:", n)
+	}
+}
+
+func TestCrawlerMaxPagesCheck(t *testing.T) {
+	t.Parallel()
+
+	var pages atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".png") {
+			w.Header().Add(contentType, "image/png")
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+
+		if r.Method != http.MethodGet {
+			return
+		}
+
+		pages.Add(1)
+		_, _ = io.WriteString(w, `<html><img src="1.png"/><img src="2.png"/><img src="3.png"/>`+
+			`<a href="a/">a</a><a href="b/">b</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithWorkersCount(1),
+		WithCheck(true),
+		WithMaxPages(3),
+	)
+
+	err := c.Run(ts.URL+"/", func(_ string) {})
+
+	var lerr LimitError
+
+	if !errors.As(err, &lerr) || lerr.Limit != limitPages {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if n := pages.Load(); n != 3 {
+		t.Error("unexpected pages count:", n)
+	}
+}
+
+func TestCrawlerMaxURLs(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="a/">a</a><a href="b/">b</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithMaxURLs(5),
+	)
+
+	var urls int
+
+	err := c.Run(ts.URL+"/", func(_ string) {
+		urls++
+	})
+
+	var lerr LimitError
+
+	if !errors.As(err, &lerr) || lerr.Limit != limitURLs {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if urls != 5 {
+		t.Error("unexpected urls count:", urls)
+	}
+}
+
+func TestCrawlerMaxDuration(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="a/">a</a><a href="b/">b</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithDelay(50*time.Millisecond),
+		WithMaxDuration(200*time.Millisecond),
+	)
+
+	err := c.Run(ts.URL+"/", func(_ string) {})
+
+	var lerr LimitError
+
+	if !errors.As(err, &lerr) || lerr.Limit != limitDuration {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func TestCrawlerMaxHops(t *testing.T) {
+	t.Parallel()
+
+	var pages atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		pages.Add(1)
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="a/">a</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithMaxHops(2),
+		WithoutHeads(true),
+	)
+
+	var res []*Result
+
+	err := c.Crawl(t.Context(), ts.URL+"/", func(r *Result) {
+		res = append(res, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// seed, and two hops from it
+	if n := pages.Load(); n != 3 {
+		t.Error("unexpected pages count:", n)
+	}
+
+	// last hop is printed, but not crawled
+	if len(res) != 3 {
+		t.Fatal("unexpected results count:", len(res))
+	}
+
+	for _, r := range res {
+		crawled := r.Status != 0
+
+		if r.Depth > 2 && crawled || r.Depth <= 2 && !crawled {
+			t.Errorf("%s: depth: %d crawled: %v", r.URL, r.Depth, crawled)
+		}
+	}
+}
+
+func TestCrawlerScopeHost(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/blog/post-1":
+			_, _ = io.WriteString(w, `<html><a href="/about">about</a></html>`)
+		case "/about":
+			_, _ = io.WriteString(w, `<html><a href="/about/team">team</a></html>`)
+		}
+	}))
+
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		scope ScopePolicy
+		want  int
+	}{
+		{scope: ScopePath, want: 1},
+		{scope: ScopeHost, want: 2},
+	} {
+		res := make(set.Unordered[string])
+
+		c := New(
+			WithMaxCrawlDepth(1),
+			WithoutHeads(true),
+			WithScopePolicy(tc.scope),
+		)
+
+		if err := c.Run(ts.URL+"/blog/post-1", func(s string) {
+			res.Add(s)
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res) != tc.want {
+			t.Errorf("scope %s: unexpected results: %v", tc.scope, set.ToSlice(res))
+		}
+	}
+}
+
+func TestCrawlerAuthHosts(t *testing.T) {
+	t.Parallel()
+
+	var other, extra atomic.Value
+
+	newServer := func(auth *atomic.Value, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth.Store(r.Header.Get("Authorization"))
+			w.Header().Add(contentType, contentHTML)
+			_, _ = io.WriteString(w, body)
+		}))
+	}
+
+	es := newServer(&extra, "")
+	defer es.Close()
+
+	fs := newServer(&other, "")
+	defer fs.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer SECRET" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = fmt.Fprintf(w, `<html><a href="%s/a">a</a><a href="%s/b">b</a></html>`, fs.URL, es.URL)
+	}))
+
+	defer ts.Close()
+
+	fu, _ := url.Parse(fs.URL)
+	eu, _ := url.Parse(es.URL)
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithScopePolicy(ScopeList),
+		WithScopeHosts([]string{fu.Host, eu.Host}),
+		WithAuth(&client.Auth{Scheme: client.AuthBearer, Token: "SECRET"}),
+		WithAuthHosts([]string{eu.Host}),
+	)
+
+	if err := c.Run(ts.URL+"/", func(_ string) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := other.Load().(string); v != "" {
+		t.Error("credentials sent to other host:", v)
+	}
+
+	if v, _ := extra.Load().(string); v != "Bearer SECRET" {
+		t.Error("credentials not sent to extra host:", v)
+	}
+}
+
+func TestCrawlerSeeds(t *testing.T) {
+	t.Parallel()
+
+	var shared string
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(contentType, contentHTML)
+
+			if r.URL.Path == "/" {
+				fmt.Fprintf(w, `<html><a href="/%s">%s</a><a href="%s">shared</a></html>`, name, name, shared)
+			}
+		}))
+	}
+
+	ts1, ts2 := newServer("one"), newServer("two")
+
+	defer ts1.Close()
+	defer ts2.Close()
+
+	shared = ts1.URL + "/one"
+
+	res := make(map[string]int)
+
+	c := New(
+		WithMaxCrawlDepth(1),
+		WithoutHeads(true),
+	)
+
+	err := c.CrawlSeeds(t.Context(), []string{ts1.URL + "/", ts2.URL + "/", ts1.URL + "/"}, func(r *Result) {
+		res[r.URL]++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 {
+		t.Errorf("unexpected results: %v", res)
+	}
+
+	for u, n := range res {
+		if n != 1 {
+			t.Errorf("duplicate result: %s", u)
+		}
+	}
+
+	if res[ts2.URL+"/two"] != 1 {
+		t.Error("second seed not crawled")
+	}
+}
+
+func TestCrawlerSeedsErrors(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+
+	if err := c.CrawlSeeds(t.Context(), []string{"%"}, func(_ *Result) {}); err == nil {
+		t.Error("bad url - no error")
+	}
+
+	u, _ := url.Parse("http://test/")
+	c.state = newState([]*url.URL{u, u})
+	c.state.pending["http://test/x"] = &crawlTask{URI: "http://test/x", Seed: 5}
+
+	err := c.CrawlSeeds(t.Context(), []string{u.String()}, func(_ *Result) {})
+	if !errors.Is(err, ErrUnknownSeed) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestCrawlerRules(t *testing.T) {
+	t.Parallel()
+
+	var pages = map[string]string{
+		"/":           `<html><a href="/docs/">docs</a><a href="/blog/">blog</a></html>`,
+		"/docs/":      `<html><a href="/docs/a.pdf">a</a><a href="/docs/next/">next</a></html>`,
+		"/docs/next/": `<html><a href="/docs/b.pdf">b</a><a href="/logout">exit</a></html>`,
+		"/blog/":      `<html><a href="/blog/c.pdf">c</a></html>`,
+	}
+
+	crawled := make(set.Unordered[string])
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		crawled.Add(r.URL.Path)
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, pages[r.URL.Path])
+	}))
+
+	defer ts.Close()
+
+	res := make(set.Unordered[string])
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithoutHeads(true),
+		WithWorkersCount(1),
+		WithCrawlRules([]string{"/docs/*"}, []string{"re:logout"}),
+		WithOutputRules([]string{"*.pdf"}, nil),
+	)
+
+	if err := c.Run(ts.URL+"/", func(s string) {
+		res.Add(s)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if crawled.Has("/blog/") || crawled.Has("/logout") {
+		t.Error("excluded pages crawled:", set.ToSlice(crawled))
+	}
+
+	if !crawled.Has("/docs/next/") {
+		t.Error("included page not crawled")
+	}
+
+	if res.Len() != 2 || !res.Has(ts.URL+"/docs/a.pdf") || !res.Has(ts.URL+"/docs/b.pdf") {
+		t.Error("unexpected results:", set.ToSlice(res))
+	}
+}
+
+func TestCrawlerCookieJar(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "1", Path: "/"})
+			_, _ = io.WriteString(w, `<html><a href="/private/">private</a></html>`)
+		case "/private/":
+			if _, err := r.Cookie("sid"); err != nil {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			_, _ = io.WriteString(w, `<html><a href="/private/secret.html">secret</a></html>`)
+		}
+	}))
+
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		jar  *client.Jar
+		want int
+	}{
+		{jar: nil, want: 1},
+		{jar: client.NewJar(), want: 2},
+	} {
+		res := make(set.Unordered[string])
+
+		c := New(
+			WithMaxCrawlDepth(-1),
+			WithoutHeads(true),
+			WithCookieJar(tc.jar),
+		)
+
+		if err := c.Run(ts.URL+"/", func(s string) {
+			res.Add(s)
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Len() != tc.want {
+			t.Errorf("jar: %v unexpected results: %v", tc.jar != nil, set.ToSlice(res))
+		}
+	}
+}
+
+func TestCrawlerLogin(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			if r.Method == http.MethodPost && r.FormValue("pass") == "secret" {
+				http.SetCookie(w, &http.Cookie{Name: "sid", Value: "1", Path: "/"})
+			}
+
+			return
+		}
+
+		if _, err := r.Cookie("sid"); err != nil {
+			http.Redirect(w, r, "/login", http.StatusFound)
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="/private/a">a</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	login := &client.Login{
+		URL: ts.URL + "/login",
+		Steps: []client.LoginStep{
+			{URL: ts.URL + "/login", Method: http.MethodPost, Form: map[string]string{"pass": "secret"}},
+		},
+	}
+
+	var res []string
+
+	c := New(WithoutHeads(true), WithLogin(login))
+
+	if err := c.Run(ts.URL+"/private/", func(s string) {
+		res = append(res, s)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 1 || res[0] != ts.URL+"/private/a" {
+		t.Error("unexpected results:", res)
+	}
+
+	login.Steps[0].Form["pass"] = "bad"
+	login.Steps[0].Expect.Status = http.StatusFound
+
+	c = New(WithoutHeads(true), WithLogin(login))
+
+	if err := c.Run(ts.URL+"/private/", func(_ string) {}); !errors.Is(err, client.ErrLoginStep) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestCrawlerAvoid(t *testing.T) {
+	t.Parallel()
+
+	var (
+		hits sync.Map
+		body = `<html>
+<a href="/logout">1</a>
+<a href="/a?action=signout">2</a>
+<a href="/blog-outline">3</a>
+</html>`
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Store(r.URL.Path, true)
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, body)
+	}))
+
+	defer ts.Close()
+
+	hit := func(opts ...Option) (rv []string) {
+		hits.Clear()
+
+		var res []string
+
+		c := New(append(opts, WithoutHeads(true), WithMaxCrawlDepth(1))...)
+
+		if err := c.Run(ts.URL+"/", func(s string) {
+			res = append(res, s)
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(res) != 3 {
+			t.Fatal("unexpected results:", res)
+		}
+
+		hits.Range(func(k, _ any) bool {
+			rv = append(rv, k.(string))
+
+			return true
+		})
+
+		slices.Sort(rv)
+
+		return rv
+	}
+
+	if got := hit(); !slices.Equal(got, []string{"/", "/blog-outline"}) {
+		t.Error("default - unexpected hits:", got)
+	}
+
+	if got := hit(WithAvoid([]string{"/blog-*"})); !slices.Equal(got, []string{"/", "/a", "/logout"}) {
+		t.Error("own - unexpected hits:", got)
+	}
+
+	if got := hit(WithoutAvoid(true)); len(got) != 4 {
+		t.Error("none - unexpected hits:", got)
+	}
+}
+
+func TestCrawlerSessionRedirect(t *testing.T) {
+	t.Parallel()
+
+	var lost atomic.Bool
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login":
+			_, _ = io.WriteString(w, "login")
+
+			return
+		case r.URL.Path == "/b":
+			lost.Store(true)
+		case lost.Load():
+			http.Redirect(w, r, "/login", http.StatusFound)
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	name := filepath.Join(t.TempDir(), "state.json")
+
+	c := New(
+		WithoutHeads(true),
+		WithMaxCrawlDepth(-1),
+		WithWorkersCount(1),
+		WithSessionCheck(true),
+		WithCheckpoint(name),
+	)
+
+	err := c.Run(ts.URL+"/", func(_ string) {})
+
+	var serr SessionError
+
+	if !errors.As(err, &serr) || !errors.Is(err, client.ErrSessionLost) {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if serr.URL != ts.URL+"/c" {
+		t.Error("unexpected url:", serr.URL)
+	}
+
+	cp, err := loadCheckpoint(name)
+	if err != nil {
+		t.Fatal("load:", err)
+	}
+
+	if len(cp.Frontier) != 1 || cp.Frontier[0].URI != ts.URL+"/c" {
+		t.Error("unexpected frontier:", cp.Frontier)
+	}
+}
+
+func TestCrawlerSessionSize(t *testing.T) {
+	t.Parallel()
+
+	var (
+		big   strings.Builder
+		small = `<html>please, log in</html>`
+	)
+
+	big.WriteString(`<html>`)
+
+	for i := range 20 {
+		fmt.Fprintf(&big, `<a href="/p%d">page</a>`, i)
+	}
+
+	big.WriteString(`</html>`)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		if n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/p")); n >= sessionWarmup {
+			_, _ = io.WriteString(w, small)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, big.String())
+	}))
+
+	defer ts.Close()
+
+	opts := []Option{
+		WithoutHeads(true),
+		WithMaxCrawlDepth(-1),
+		WithWorkersCount(1),
+	}
+
+	if err := New(opts...).Run(ts.URL+"/", func(_ string) {}); err != nil {
+		t.Fatal("no check - unexpected error:", err)
+	}
+
+	err := New(append(opts, WithSessionCheck(true))...).Run(ts.URL+"/", func(_ string) {})
+	if !errors.Is(err, client.ErrSessionLost) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestCrawlerRetry(t *testing.T) {
+	t.Parallel()
+
+	var hitsA, hitsB atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			if hitsA.Add(1) <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+		case "/b":
+			hitsB.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		case "/c":
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+		_, _ = io.WriteString(w, `<html><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></html>`)
+	}))
+
+	defer ts.Close()
+
+	res := make(map[string]int)
+
+	c := New(
+		WithoutHeads(true),
+		WithMaxCrawlDepth(-1),
+		WithRetries(2),
+		WithRetryWait(time.Millisecond),
+	)
+
+	if err := c.Crawl(t.Context(), ts.URL+"/", func(r *Result) {
+		res[r.URL] = r.Status
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if res[ts.URL+"/a"] != http.StatusOK || res[ts.URL+"/b"] != http.StatusBadGateway ||
+		res[ts.URL+"/c"] != http.StatusNotFound {
+		t.Error("unexpected results:", res)
+	}
+
+	if hitsA.Load() != 3 || hitsB.Load() != 3 {
+		t.Error("unexpected hits:", hitsA.Load(), hitsB.Load())
+	}
+
+	f := c.Failures()
+	if len(f) != 1 || f[0].URL != ts.URL+"/b" || f[0].Status != http.StatusBadGateway || f[0].Tries != 3 {
+		t.Fatal("unexpected failures:", f)
+	}
+
+	// no retries
+	hitsA.Store(0)
+
+	c = New(WithoutHeads(true), WithMaxCrawlDepth(-1))
+
+	if err := c.Crawl(t.Context(), ts.URL+"/", func(_ *Result) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if f = c.Failures(); len(f) != 2 || f[0].Tries != 1 {
+		t.Error("no retries - unexpected failures:", f)
+	}
+}
+
+func TestCrawlerRedirects(t *testing.T) {
+	t.Parallel()
+
+	var away atomic.Int32
+
+	ts2 := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		away.Add(1)
+	}))
+
+	defer ts2.Close()
+
+	var hits sync.Map
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			n, _ := hits.LoadOrStore(r.URL.Path, new(atomic.Int32))
+			n.(*atomic.Int32).Add(1)
+		}
+
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/docs/":
+			_, _ = io.WriteString(w, `<a href="old">1</a><a href="away">2</a><a href="up">3</a>`)
+		case "/docs/old":
+			http.Redirect(w, r, "/docs/new/", http.StatusMovedPermanently)
+		case "/docs/away":
+			http.Redirect(w, r, ts2.URL+"/x", http.StatusFound)
+		case "/docs/up":
+			http.Redirect(w, r, "/outside", http.StatusFound)
+		case "/docs/new/":
+			_, _ = io.WriteString(w, `<a href="page">4</a>`)
+		}
+	}))
+
+	defer ts.Close()
+
+	crawl := func(opts ...Option) (rv map[string]*Result) {
+		hits.Clear()
+
+		rv = make(map[string]*Result)
+
+		c := New(append(opts, WithMaxCrawlDepth(-1))...)
+
+		if err := c.Crawl(t.Context(), ts.URL+"/docs/", func(r *Result) {
+			if _, ok := rv[r.URL]; ok {
+				t.Error("duplicate:", r.URL)
+			}
+
+			rv[r.URL] = r
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		return rv
+	}
+
+	gets := func(path string) (n int32) {
+		if v, ok := hits.Load(path); ok {
+			n = v.(*atomic.Int32).Load()
+		}
+
+		return n
+	}
+
+	res := crawl()
+
+	old := res[ts.URL+"/docs/old"]
+	if old == nil || old.Status != http.StatusOK ||
+		old.RedirectChain() != ts.URL+"/docs/old -> 301 -> "+ts.URL+"/docs/new/" {
+		t.Fatalf("unexpected old: %+v", old)
+	}
+
+	// links are resolved against redirect target, which is not crawled once again
+	if res[ts.URL+"/docs/new/page"] == nil || res[ts.URL+"/docs/new/"] == nil || gets("/docs/new/") != 1 {
+		t.Error("unexpected redirect target:", res, gets("/docs/new/"))
+	}
+
+	if r := res[ts.URL+"/docs/away"]; r == nil || r.Status != http.StatusFound ||
+		len(r.Redirects) != 1 || r.Redirects[0].URL != ts2.URL+"/x" {
+		t.Errorf("unexpected away: %+v", r)
+	}
+
+	if res[ts2.URL+"/x"] == nil || away.Load() != 0 {
+		t.Error("off-site redirect is followed")
+	}
+
+	if r := res[ts.URL+"/docs/up"]; r == nil || r.Status != http.StatusFound || gets("/outside") != 0 {
+		t.Errorf("out of scope redirect is followed: %+v", r)
+	}
+
+	// redirects are not followed, but targets are crawled on their own
+	res = crawl(WithMaxRedirects(-1), WithoutHeads(true))
+
+	if r := res[ts.URL+"/docs/old"]; r == nil || r.Status != http.StatusMovedPermanently || len(r.Redirects) != 1 {
+		t.Errorf("no follow - unexpected old: %+v", r)
+	}
+
+	if res[ts.URL+"/docs/new/page"] == nil || gets("/docs/new/") != 1 {
+		t.Error("no follow - target is not crawled")
+	}
+}
+
+func TestCrawlerStatus(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = io.WriteString(w, `<a href="/gone">1</a><a href="/denied">2</a>`+
+				`<a href="/empty">3</a><a href="/missing">4</a>`)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/denied":
+			w.WriteHeader(http.StatusForbidden)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ts.Close()
+
+	want := map[string]string{
+		"/gone":    "410 Gone",
+		"/denied":  "403 Forbidden",
+		"/empty":   "204 No Content",
+		"/missing": "404 Not Found",
+	}
+
+	for _, noHEAD := range []bool{false, true} {
+		got := make(map[string]string)
+
+		c := New(WithMaxCrawlDepth(1), WithoutHeads(noHEAD), WithRetries(0))
+
+		if err := c.Crawl(t.Context(), ts.URL+"/", func(r *Result) {
+			got[strings.TrimPrefix(r.URL, ts.URL)] = fmt.Sprintf("%d %s", r.Status, r.Reason)
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if !maps.Equal(got, want) {
+			t.Errorf("headless: %t unexpected results: %v", noHEAD, got)
+		}
+	}
+}
+
+func TestCrawlerCheck(t *testing.T) {
+	t.Parallel()
+
+	var logout atomic.Int32
+
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ext.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Add(contentType, contentHTML)
+			_, _ = io.WriteString(w, `<a href="/a">a</a><a href="/gone">g</a><img src="/img.png">
+<a href="/api">api</a><img src="/api?v=1"><a href="/logout">bye</a>`+
+				`<a href="`+ext.URL+`/ok">ok</a><a href="`+ext.URL+`/bad">bad</a>`)
+		case "/a":
+			w.Header().Add(contentType, contentHTML)
+			_, _ = io.WriteString(w, `<a href="/gone">g</a><img src="/img.png">`)
+		case "/api":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/logout":
+			logout.Add(1)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ts.Close()
+
+	c := New(WithMaxCrawlDepth(-1), WithCheck(true))
+
+	if err := c.Crawl(t.Context(), ts.URL+"/", func(_ *Result) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := c.Broken()
+
+	want := []Broken{
+		{URL: ext.URL + "/bad", Status: http.StatusNotFound, Sources: []string{ts.URL + "/"}},
+		{URL: ts.URL + "/gone", Status: http.StatusGone, Sources: []string{ts.URL + "/", ts.URL + "/a"}},
+		{URL: ts.URL + "/img.png", Status: http.StatusNotFound, Sources: []string{ts.URL + "/", ts.URL + "/a"}},
+	}
+
+	slices.SortFunc(want, func(a, b Broken) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+
+	if len(got) != len(want) {
+		t.Fatal("unexpected broken:", got)
+	}
+
+	for i := range want {
+		if got[i].URL != want[i].URL || got[i].Status != want[i].Status ||
+			got[i].Refs != len(want[i].Sources) || !slices.Equal(got[i].Sources, want[i].Sources) {
+			t.Errorf("%d: want: %+v got: %+v", i, want[i], got[i])
+		}
+	}
+
+	if logout.Load() != 0 {
+		t.Error("avoided link is checked")
+	}
+}
+
+func TestCrawlerCheckForeignNoCredentials(t *testing.T) {
+	t.Parallel()
+
+	var (
+		hits    atomic.Int32
+		leaks   atomic.Int32
+		proxied atomic.Int32
+	)
+
+	ext := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Token") != "" || len(r.Cookies()) > 0 {
+			leaks.Add(1)
+		}
+
+		// proxy credentials are still required to reach foreign host
+		if strings.HasPrefix(r.Header.Get(proxyAuthKey), proxyAuthBasic+" ") {
+			proxied.Add(1)
+		}
+	}))
+
+	defer ext.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer SECRET" || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Add(contentType, contentHTML)
+
+		if r.URL.Path == "/" {
+			_, _ = io.WriteString(w, `<a href="/a">a</a><a href="`+ext.URL+`/page">ext</a><img src="`+ext.URL+`/img.png">`)
+		}
+	}))
+
+	defer ts.Close()
+
+	eu, _ := url.Parse(ext.URL)
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithCheck(true),
+		WithAuth(&client.Auth{Scheme: client.AuthBearer, Token: "SECRET"}),
+		// credentials, explicitly allowed for foreign host, are not sent to it by checks either
+		WithAuthHosts([]string{eu.Host}),
+		WithExtraHeaders([]string{"X-Token: secret"}),
+		WithExtraCookies([]string{"session=secret"}),
+		WithProxyAuth("user:pass"),
+	)
+
+	if err := c.Run(ts.URL+"/", func(_ string) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := c.Broken(); len(b) != 0 {
+		t.Error("unexpected broken:", b)
+	}
+
+	if n := hits.Load(); n != 2 {
+		t.Error("unexpected foreign hits:", n)
+	}
+
+	if n := proxied.Load(); n != 2 {
+		t.Error("proxy credentials were not sent to foreign host:", n)
+	}
+
+	if n := leaks.Load(); n != 0 {
+		t.Error("credentials sent to foreign host:", n)
+	}
+}
+
+func TestCrawlerCheckRedirectForeign(t *testing.T) {
+	t.Parallel()
+
+	var leaks atomic.Int32
+
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Secret") != "" {
+			leaks.Add(1)
+		}
+
+		if r.URL.Path == "/gone.png" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer ext.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Add(contentType, contentHTML)
+			_, _ = io.WriteString(w, `<img src="/img.png"><img src="/gone.png">`)
+		default:
+			http.Redirect(w, r, ext.URL+r.URL.Path, http.StatusFound)
+		}
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithCheck(true),
+		WithExtraHeaders([]string{"X-Secret: secret"}),
+	)
+
+	if err := c.Run(ts.URL+"/", func(_ string) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := leaks.Load(); n != 0 {
+		t.Error("credentials sent to foreign host:", n)
+	}
+
+	b := c.Broken()
+	if len(b) != 1 {
+		t.Fatal("unexpected broken:", b)
+	}
+
+	if b[0].URL != ts.URL+"/gone.png" || b[0].Status != http.StatusNotFound {
+		t.Error("unexpected broken link:", b[0])
+	}
+}
+
+func TestCrawlerCheckExternal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		hits   sync.Map
+		leaked atomic.Bool
+	)
+
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := hits.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		n.(*atomic.Int32).Add(1)
+
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Secret") != "" {
+			leaked.Store(true)
+		}
+
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ext.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = io.WriteString(w, `<a href="/a">a</a><a href="/missing">m</a>
+<a href="`+ext.URL+`/ok">ok</a><a href="`+ext.URL+`/bad">bad</a><img src="`+ext.URL+`/bad.png">`)
+		case "/a":
+			_, _ = io.WriteString(w, `<a href="`+ext.URL+`/bad">bad</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ts.Close()
+
+	c := New(
+		WithMaxCrawlDepth(-1),
+		WithCheckExternal(true),
+		WithExternalWorkers(2),
+		WithExtraHeaders([]string{"X-Secret: 1"}),
+		WithAuth(&client.Auth{Token: "token", Scheme: client.AuthBearer}),
+	)
+
+	if err := c.Crawl(t.Context(), ts.URL+"/", func(_ *Result) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := c.Broken(); len(b) != 0 {
+		t.Error("internal links are checked:", b)
+	}
+
+	got := c.BrokenExternal()
+
+	if len(got) != 2 || got[0].URL != ext.URL+"/bad" || got[1].URL != ext.URL+"/bad.png" {
+		t.Fatal("unexpected broken:", got)
+	}
+
+	if got[0].Status != http.StatusNotFound || got[0].Refs != 2 || got[1].Refs != 1 {
+		t.Errorf("unexpected broken: %+v", got)
+	}
+
+	hits.Range(func(k, v any) bool {
+		if n := v.(*atomic.Int32).Load(); n != 1 {
+			t.Errorf("%s: unexpected hits: %d", k, n)
+		}
+
+		return true
+	})
+
+	if leaked.Load() {
+		t.Error("credentials are sent to external host")
+	}
+
+	// internal links are checked by crawl workers, and reported separately
+	c = New(WithMaxCrawlDepth(-1), WithCheck(true), WithCheckExternal(true))
+
+	if err := c.Crawl(t.Context(), ts.URL+"/", func(_ *Result) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b := c.Broken(); len(b) != 1 || b[0].URL != ts.URL+"/missing" {
+		t.Error("check - unexpected broken:", b)
+	}
+
+	if b := c.BrokenExternal(); len(b) != 2 {
+		t.Error("check - unexpected external:", b)
+	}
+}
+
+func TestCrawlerAnchors(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(contentType, contentHTML)
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = io.WriteString(w, `<h1 id="top-title">t</h1><a href="#top-title">1</a><a href="#gone">2</a>
+<a href="/guide#install">3</a><a href="/guide#setup">4</a><a href="/old#usage">5</a><a href="#/route">6</a>`)
+		case "/old":
+			http.Redirect(w, r, "/guide", http.StatusMovedPermanently)
+		case "/guide":
+			_, _ = io.WriteString(w, `<h2 id="install">i</h2><a name="usage">u</a><a href="/#gone">back</a>`)
+		}
+	}))
+
+	defer ts.Close()
+
+	crawl := func(opts ...Option) *Crawler {
+		c := New(append(opts, WithMaxCrawlDepth(-1))...)
+
+		if err := c.Crawl(t.Context(), ts.URL+"/", func(_ *Result) {}); err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	}
+
+	if b := crawl().BrokenAnchors(); len(b) != 0 {
+		t.Error("disabled - unexpected broken:", b)
+	}
+
+	b := crawl(WithCheckAnchors(true)).BrokenAnchors()
+
+	if len(b) != 2 {
+		t.Fatal("unexpected broken:", b)
+	}
+
+	if b[0].URL != ts.URL+"/#gone" || b[0].Refs != 2 {
+		t.Errorf("unexpected broken: %+v", b[0])
+	}
+
+	if b[1].URL != ts.URL+"/guide#setup" || !slices.Equal(b[1].Sources, []string{ts.URL + "/"}) {
+		t.Errorf("unexpected broken: %+v", b[1])
+	}
+}
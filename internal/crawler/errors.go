@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+)
+
+// CancelError is returned by RunContext, when crawling was interrupted by context.
+type CancelError struct {
+	err error
+}
+
+// Error return error textual representation.
+func (cerr CancelError) Error() string {
+	return "crawl canceled: " + cerr.err.Error()
+}
+
+// Unwrap returns context error, caused this cancellation.
+func (cerr CancelError) Unwrap() error {
+	return cerr.err
+}
+
+// LimitError is returned by Crawl, when crawling was stopped by one of its budgets.
+type LimitError struct {
+	// Limit is a name of limit: pages / urls / bytes / duration.
+	Limit string
+	// Max is a limit value.
+	Max string
+}
+
+// Error return error textual representation.
+func (lerr LimitError) Error() string {
+	return "max " + lerr.Limit + " limit reached: " + lerr.Max
+}
+
+// SessionError is returned by Crawl, when crawling was paused due to session loss, crawl can be
+// continued from checkpoint (if any), once session is restored.
+type SessionError struct {
+	// URL is an url, session loss was detected at.
+	URL string
+	Err error
+}
+
+// Error return error textual representation.
+func (serr SessionError) Error() string {
+	return "crawl paused at " + serr.URL + ": " + serr.Err.Error()
+}
+
+// Unwrap returns error, caused this pause.
+func (serr SessionError) Unwrap() error {
+	return serr.Err
+}
+
+func stopError(ctx context.Context) (err error) {
+	cause := context.Cause(ctx)
+
+	var (
+		lerr LimitError
+		serr SessionError
+	)
+
+	switch {
+	case errors.As(cause, &lerr):
+		return lerr
+	case errors.As(cause, &serr):
+		return serr
+	}
+
+	return CancelError{err: cause}
+}
@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// DefaultExternalWorkers is a default count of workers, that check external links.
+	DefaultExternalWorkers = 4
+	// DefaultExternalRate is a default requests rate (per second) for every external host.
+	DefaultExternalRate = 1.0
+)
+
+// externalResult is a cached result of external link check.
+type externalResult struct {
+	err    error
+	status int
+}
+
+// externals checks out-of-scope links: every url is requested once, with own workers and client,
+// that has own per-host limits, and knows nothing about crawl credentials.
+type externals struct {
+	web     crawlClient
+	queue   *frontier
+	cache   map[string]externalResult
+	pending sync.WaitGroup // queued checks
+	wg      sync.WaitGroup // workers
+	mu      sync.Mutex
+}
+
+func newExternals(cfg *config) (x *externals) {
+	x = &externals{
+		web:   newBareClient(cfg, cfg.ExternalWorkers, 1, cfg.ExternalRate),
+		cache: make(map[string]externalResult),
+	}
+
+	x.queue = newFrontier(cfg.FrontierSize, func(n int) {
+		x.pending.Add(-n)
+	})
+
+	return x
+}
+
+// Push queues url check.
+func (x *externals) Push(t *crawlTask) {
+	x.pending.Add(1)
+	x.queue.Push(t)
+}
+
+// Wait waits for all queued checks to complete, and stops workers.
+func (x *externals) Wait() {
+	x.pending.Wait()
+	x.queue.Close()
+	x.wg.Wait()
+}
+
+// Broken returns urls, found broken, sorted by url.
+func (x *externals) Broken() (rv []Broken) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for uri, res := range x.cache {
+		if isBroken(res.status, res.err) {
+			rv = insertBroken(rv, Broken{URL: uri, Error: res.err.Error(), Status: res.status})
+		}
+	}
+
+	return rv
+}
+
+func (x *externals) cached(uri string) (yes bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	_, yes = x.cache[uri]
+
+	return yes
+}
+
+func (x *externals) store(uri string, status int, err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.cache[uri] = externalResult{status: status, err: err}
+}
+
+// startExternals starts external links checkers, if enabled.
+func (c *Crawler) startExternals(ctx context.Context) {
+	if !c.cfg.CheckExternal {
+		return
+	}
+
+	c.ext = newExternals(c.cfg)
+	c.ext.wg.Add(c.cfg.ExternalWorkers)
+
+	for range c.cfg.ExternalWorkers {
+		go c.externalWorker(ctx, c.ext)
+	}
+}
+
+func (c *Crawler) externalWorker(ctx context.Context, x *externals) {
+	defer x.wg.Done()
+
+	for task := range x.queue.out {
+		// on cancel - just drain queue
+		if ctx.Err() == nil && !x.cached(task.URI) {
+			tctx, cancel := context.WithTimeout(ctx, c.cfg.Client.Timeout)
+			resp, err := checkURL(tctx, x.web, task.URI)
+
+			cancel()
+
+			x.store(task.URI, resp.Code, err)
+		}
+
+		x.pending.Done()
+	}
+}
+
+// tryExternal creates check task for found url, if it leads to host, that is out of crawl scope.
+func (c *Crawler) tryExternal(r *crawlResult) (t *crawlTask, yes bool) {
+	u, ok := c.checkable(r)
+	if !ok || c.scope.Host(c.seeds[r.Seed].URL, u) {
+		return nil, false
+	}
+
+	t = newTask(u)
+	t.Seed = r.Seed
+	t.Check = true
+
+	return t, true
+}
+
+// BrokenExternal returns external links, found broken during last crawl, sorted by url.
+func (c *Crawler) BrokenExternal() []Broken {
+	if c.ext == nil {
+		return nil
+	}
+
+	return c.refs.Fill(c.ext.Broken())
+}
@@ -0,0 +1,286 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+)
+
+const spillPattern = "crawley-frontier-*"
+
+// crawlTask is a single unit of work for crawler.
+type crawlTask struct {
+	URL    *url.URL `json:"-"`
+	Result *Result  `json:"result,omitempty"` // to be emitted, when task is done
+	URI    string   `json:"uri"`
+	Depth  int      `json:"depth"`
+	Seed   int      `json:"seed,omitempty"`  // index of seed, task was found from
+	Tries  int      `json:"tries,omitempty"` // failed attempts count
+	Check  bool     `json:"check,omitempty"` // url is only checked, not crawled
+}
+
+func newTask(u *url.URL) (t *crawlTask) {
+	return &crawlTask{URL: u, URI: u.String()}
+}
+
+// frontier is an unbounded, lossless FIFO queue of crawl tasks, it keeps up to limit
+// tasks in memory and spills the rest to temporary file on disk. Spilled tasks, that cannot
+// be read back, are reported to lost, so their owner can count them as done.
+type frontier struct {
+	in    chan *crawlTask
+	out   chan *crawlTask
+	lost  func(n int)
+	spill *spillFile
+	mem   []*crawlTask
+	limit int
+}
+
+func newFrontier(limit int, lost func(n int)) (f *frontier) {
+	f = &frontier{
+		in:    make(chan *crawlTask),
+		out:   make(chan *crawlTask),
+		lost:  lost,
+		limit: limit,
+	}
+
+	go f.run()
+
+	return f
+}
+
+// Push adds task to queue, it never drops tasks and never blocks for long.
+func (f *frontier) Push(t *crawlTask) {
+	f.in <- t
+}
+
+// Close stops queue, removing spill file, if any, tasks channel will be closed.
+func (f *frontier) Close() {
+	close(f.in)
+}
+
+func (f *frontier) run() {
+	defer func() {
+		f.spill.Close()
+		close(f.out)
+	}()
+
+	var (
+		next *crawlTask
+		out  chan<- *crawlTask
+	)
+
+	for {
+		out, next = nil, nil
+
+		if len(f.mem) == 0 && f.spill.Len() > 0 {
+			f.refill()
+		}
+
+		if len(f.mem) > 0 {
+			out, next = f.out, f.mem[0]
+		}
+
+		select {
+		case t, ok := <-f.in:
+			if !ok {
+				return
+			}
+
+			f.push(t)
+		case out <- next:
+			f.pop()
+		}
+	}
+}
+
+func (f *frontier) push(t *crawlTask) {
+	if f.spill.Len() == 0 && len(f.mem) < f.limit {
+		f.mem = append(f.mem, t)
+
+		return
+	}
+
+	err := f.spillTask(t)
+	if err == nil {
+		return
+	}
+
+	log.Println("[-] frontier:", err)
+
+	// no luck with disk - keep it in memory, anyway
+	f.mem = append(f.mem, t)
+}
+
+func (f *frontier) spillTask(t *crawlTask) (err error) {
+	if f.spill == nil {
+		if f.spill, err = newSpillFile(); err != nil {
+			return err
+		}
+	}
+
+	return f.spill.Write(t)
+}
+
+func (f *frontier) pop() {
+	f.mem[0] = nil
+	f.mem = f.mem[1:]
+
+	if len(f.mem) == 0 {
+		f.mem = nil
+	}
+}
+
+// refill reads next batch of spilled tasks, on i/o errors spill file is dropped, and all of
+// its tasks are reported as lost, as well as tasks, that cannot be decoded.
+func (f *frontier) refill() {
+	var (
+		bad int
+		err error
+	)
+
+	f.mem, bad, err = f.spill.Read(f.limit)
+	if err != nil {
+		log.Println("[-] frontier:", err)
+
+		bad += f.spill.Len()
+
+		f.spill.Close()
+		f.spill = nil
+	}
+
+	if bad > 0 {
+		log.Printf("[-] frontier: %d task(s) lost", bad)
+
+		f.lost(bad)
+	}
+}
+
+// spillFile is an on-disk FIFO of tasks, stored as json lines.
+type spillFile struct {
+	fd    *os.File
+	w     *bufio.Writer
+	roff  int64
+	woff  int64
+	count int
+}
+
+func newSpillFile() (s *spillFile, err error) {
+	fd, err := os.CreateTemp("", spillPattern)
+	if err != nil {
+		return nil, fmt.Errorf("create spill: %w", err)
+	}
+
+	return &spillFile{fd: fd, w: bufio.NewWriter(fd)}, nil
+}
+
+// Len returns count of spilled tasks, it is nil-safe.
+func (s *spillFile) Len() (n int) {
+	if s == nil {
+		return 0
+	}
+
+	return s.count
+}
+
+// Write appends task to file.
+func (s *spillFile) Write(t *crawlTask) (err error) {
+	buf, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	n, err := s.w.Write(append(buf, '\n'))
+	if err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	s.woff += int64(n)
+	s.count++
+
+	return nil
+}
+
+// Read reads up to n oldest tasks from file, file is truncated, once all tasks are read.
+// Tasks, that cannot be decoded, are skipped and counted as bad.
+func (s *spillFile) Read(n int) (rv []*crawlTask, bad int, err error) {
+	if err = s.w.Flush(); err != nil {
+		return nil, 0, fmt.Errorf("flush: %w", err)
+	}
+
+	var (
+		r    = bufio.NewReader(io.NewSectionReader(s.fd, s.roff, s.woff-s.roff))
+		line []byte
+	)
+
+	rv = make([]*crawlTask, 0, min(n, s.count))
+
+	for ; n > 0 && s.count > 0; n-- {
+		if line, err = r.ReadBytes('\n'); err != nil {
+			return rv, bad, fmt.Errorf("read: %w", err)
+		}
+
+		s.roff += int64(len(line))
+		s.count--
+
+		var t *crawlTask
+
+		if t, err = decodeTask(line); err != nil {
+			log.Println("[-] frontier:", err)
+
+			bad++
+
+			continue
+		}
+
+		rv = append(rv, t)
+	}
+
+	if s.count == 0 {
+		return rv, bad, s.reset()
+	}
+
+	return rv, bad, nil
+}
+
+func decodeTask(line []byte) (t *crawlTask, err error) {
+	t = &crawlTask{}
+
+	if err = json.Unmarshal(line, t); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if t.URL, err = url.Parse(t.URI); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	return t, nil
+}
+
+// Close closes and removes file, it is nil-safe.
+func (s *spillFile) Close() {
+	if s == nil {
+		return
+	}
+
+	_ = s.fd.Close()
+	_ = os.Remove(s.fd.Name())
+}
+
+func (s *spillFile) reset() (err error) {
+	if err = s.fd.Truncate(0); err != nil {
+		return fmt.Errorf("truncate: %w", err)
+	}
+
+	if _, err = s.fd.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek: %w", err)
+	}
+
+	s.w.Reset(s.fd)
+	s.roff, s.woff = 0, 0
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+func TestFrontierOrder(t *testing.T) {
+	t.Parallel()
+
+	const count = 50
+
+	f := newFrontier(7, func(int) { t.Error("lost tasks") })
+
+	for i := range count {
+		u, _ := url.Parse(fmt.Sprintf("http://test/%d", i))
+		f.Push(newTask(u))
+	}
+
+	for i := range count {
+		task := <-f.out
+
+		if want := fmt.Sprintf("http://test/%d", i); task.URI != want || task.URL.String() != want {
+			t.Fatalf("unexpected task at %d: %s", i, task.URI)
+		}
+	}
+
+	f.Close()
+
+	if _, ok := <-f.out; ok {
+		t.Error("tasks channel not closed")
+	}
+}
+
+func TestSpillFile(t *testing.T) {
+	t.Parallel()
+
+	s, err := newSpillFile()
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	defer s.Close()
+
+	for i := range 5 {
+		u, _ := url.Parse(fmt.Sprintf("http://test/%d", i))
+
+		if err = s.Write(newTask(u)); err != nil {
+			t.Fatal("write:", err)
+		}
+	}
+
+	got, bad, err := s.Read(3)
+	if err != nil {
+		t.Fatal("read:", err)
+	}
+
+	if len(got) != 3 || bad != 0 || s.Len() != 2 || got[2].URI != "http://test/2" {
+		t.Fatal("unexpected first batch")
+	}
+
+	if got, _, err = s.Read(10); err != nil {
+		t.Fatal("read:", err)
+	}
+
+	if len(got) != 2 || s.Len() != 0 || got[1].URI != "http://test/4" {
+		t.Fatal("unexpected second batch")
+	}
+
+	if s.roff != 0 || s.woff != 0 {
+		t.Error("not reset")
+	}
+
+	var nilSpill *spillFile
+
+	if nilSpill.Len() != 0 {
+		t.Error("nil length")
+	}
+
+	nilSpill.Close()
+}
+
+func TestSpillFileBad(t *testing.T) {
+	t.Parallel()
+
+	s, err := newSpillFile()
+	if err != nil {
+		t.Fatal("create:", err)
+	}
+
+	defer s.Close()
+
+	u, _ := url.Parse("http://test/")
+
+	_ = s.Write(newTask(u))
+	n, _ := s.w.WriteString("{\n")
+	s.woff += int64(n)
+	s.count++
+	_ = s.Write(newTask(u))
+
+	got, bad, err := s.Read(10)
+	if err != nil {
+		t.Fatal("read:", err)
+	}
+
+	if len(got) != 2 || bad != 1 || s.Len() != 0 {
+		t.Error("unexpected result:", len(got), bad)
+	}
+}
+
+func TestFrontierLost(t *testing.T) {
+	t.Parallel()
+
+	lost := make(chan int, 1)
+
+	f := &frontier{
+		in:    make(chan *crawlTask),
+		out:   make(chan *crawlTask),
+		lost:  func(n int) { lost <- n },
+		limit: 1,
+	}
+
+	for i := range 3 {
+		u, _ := url.Parse(fmt.Sprintf("http://test/%d", i))
+		f.push(newTask(u))
+	}
+
+	if f.spill.Len() != 2 {
+		t.Fatal("not spilled")
+	}
+
+	// spilled tasks cannot be read back
+	_ = f.spill.fd.Close()
+
+	go f.run()
+
+	if task := <-f.out; task.URI != "http://test/0" {
+		t.Error("unexpected task:", task.URI)
+	}
+
+	if n := <-lost; n != 2 {
+		t.Error("unexpected lost count:", n)
+	}
+
+	u, _ := url.Parse("http://test/3")
+	f.Push(newTask(u))
+
+	if task := <-f.out; task.URI != "http://test/3" {
+		t.Error("unexpected task after loss:", task.URI)
+	}
+
+	f.Close()
+
+	if _, ok := <-f.out; ok {
+		t.Error("tasks channel not closed")
+	}
+}
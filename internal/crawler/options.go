@@ -1,7 +1,11 @@
 package crawler
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"time"
+
+	"github.com/s0rg/crawley/internal/client"
 )
 
 // Option is a configuration func.
@@ -28,6 +32,14 @@ func WithMaxCrawlDepth(v int) Option {
 	}
 }
 
+// WithMaxHops sets maximum count of link hops from starting url to crawl, 0 means no limit.
+// It is checked in addition to path depth, so both of them should pass.
+func WithMaxHops(v int) Option {
+	return func(c *config) {
+		c.Hops = v
+	}
+}
+
 // WithWorkersCount sets maximum workers.
 func WithWorkersCount(v int) Option {
 	return func(c *config) {
@@ -98,6 +110,82 @@ func WithIgnored(v []string) Option {
 	}
 }
 
+// WithAvoid replaces default patterns (regex or glob) for links, that can end session, they are not crawled.
+func WithAvoid(v []string) Option {
+	return func(c *config) {
+		c.Avoid = append(c.Avoid, v...)
+	}
+}
+
+// WithoutAvoid disables links avoidance.
+func WithoutAvoid(v bool) Option {
+	return func(c *config) {
+		c.NoAvoid = v
+	}
+}
+
+// WithSessionCheck enables session loss detection: by redirect to login page, or by same (and much
+// smaller, than usual) responses in a row, crawl is paused, once it is detected.
+func WithSessionCheck(v bool) Option {
+	return func(c *config) {
+		c.Client.SessionCheck = v
+	}
+}
+
+// WithCheck enables broken links check: every found url, that is not crawled, is requested
+// (with HEAD, or GET, if HEAD is not allowed) to get its status.
+func WithCheck(v bool) Option {
+	return func(c *config) {
+		c.Check = v
+	}
+}
+
+// WithCheckExternal enables external links check: every found url, that leads to out-of-scope host, is
+// requested once, by its own pool of workers.
+func WithCheckExternal(v bool) Option {
+	return func(c *config) {
+		c.CheckExternal = v
+	}
+}
+
+// WithCheckAnchors enables fragments check: links to anchors (element ids, or names of a tags), that
+// are missing on crawled pages, are collected.
+func WithCheckAnchors(v bool) Option {
+	return func(c *config) {
+		c.CheckAnchors = v
+	}
+}
+
+// WithExternalWorkers sets count of workers, that check external links.
+func WithExternalWorkers(v int) Option {
+	return func(c *config) {
+		c.ExternalWorkers = v
+	}
+}
+
+// WithExternalRate sets max requests per second, for every external host.
+func WithExternalRate(v float64) Option {
+	return func(c *config) {
+		c.ExternalRate = v
+	}
+}
+
+// WithCrawlRules sets include / exclude patterns (regex or glob) for urls to crawl.
+func WithCrawlRules(include, exclude []string) Option {
+	return func(c *config) {
+		c.CrawlInclude = append(c.CrawlInclude, include...)
+		c.CrawlExclude = append(c.CrawlExclude, exclude...)
+	}
+}
+
+// WithOutputRules sets include / exclude patterns (regex or glob) for urls to report.
+func WithOutputRules(include, exclude []string) Option {
+	return func(c *config) {
+		c.OutputInclude = append(c.OutputInclude, include...)
+		c.OutputExclude = append(c.OutputExclude, exclude...)
+	}
+}
+
 // WithScanJS enables js scanning.
 func WithScanJS(v bool) Option {
 	return func(c *config) {
@@ -109,11 +197,96 @@ func WithScanJS(v bool) Option {
 func WithProxyAuth(v string) Option {
 	return func(c *config) {
 		if v != "" {
-			c.Client.Headers = append(c.Client.Headers, proxyAuthHeader(v))
+			c.Client.ProxyAuth = proxyAuthHeader(v)
 		}
 	}
 }
 
+// WithCookieJar sets cookie jar, shared by all requests, it keeps cookies, set by site.
+func WithCookieJar(v *client.Jar) Option {
+	return func(c *config) {
+		c.Client.Jar = v
+	}
+}
+
+// WithLogin sets login recipe, it is run before crawl, and again, if session is lost.
+func WithLogin(v *client.Login) Option {
+	return func(c *config) {
+		c.Client.Login = v
+	}
+}
+
+// WithAuth sets http authentication credentials.
+func WithAuth(v *client.Auth) Option {
+	return func(c *config) {
+		c.Client.Auth = v
+	}
+}
+
+// WithAuthHosts sets extra hosts, auth credentials (and oauth token) are sent to, besides seed hosts.
+func WithAuthHosts(v []string) Option {
+	return func(c *config) {
+		c.Client.AuthHosts = v
+	}
+}
+
+// WithOAuth sets OAuth2 client credentials, access token is obtained before crawl and refreshed, when needed.
+func WithOAuth(v *client.OAuth) Option {
+	return func(c *config) {
+		c.Client.OAuth = v
+	}
+}
+
+// WithClientCert sets client certificate for mTLS.
+func WithClientCert(v *tls.Certificate) Option {
+	return func(c *config) {
+		c.Client.Cert = v
+	}
+}
+
+// WithRootCAs sets CA bundle to verify servers certificates with.
+func WithRootCAs(v *x509.CertPool) Option {
+	return func(c *config) {
+		c.Client.RootCAs = v
+	}
+}
+
+// WithHostRate sets max requests per second, for every host.
+func WithHostRate(v float64) Option {
+	return func(c *config) {
+		c.Client.HostRate = v
+	}
+}
+
+// WithHostConns sets max concurrent requests, for every host.
+func WithHostConns(v int) Option {
+	return func(c *config) {
+		c.Client.HostConns = v
+	}
+}
+
+// WithMaxRedirects sets max count of redirects, followed for single request, negative value
+// disables redirects following.
+func WithMaxRedirects(v int) Option {
+	return func(c *config) {
+		c.Client.MaxRedirects = v
+	}
+}
+
+// WithRetries sets max count of retries for failed (due to network or server errors) requests.
+func WithRetries(v int) Option {
+	return func(c *config) {
+		c.Retries = v
+	}
+}
+
+// WithRetryWait sets base interval between retries, it is doubled (and jittered) for every try.
+func WithRetryWait(v time.Duration) Option {
+	return func(c *config) {
+		c.RetryWait = v
+	}
+}
+
 // WithTimeout sets request timeout.
 func WithTimeout(v time.Duration) Option {
 	return func(c *config) {
@@ -128,9 +301,79 @@ func WithScanCSS(v bool) Option {
 	}
 }
 
+// WithScopePolicy sets ScopePolicy for crawler.
+func WithScopePolicy(v ScopePolicy) Option {
+	return func(c *config) {
+		c.Scope = v
+	}
+}
+
+// WithScopeHosts sets extra hosts, allowed to crawl with ScopeList policy.
+func WithScopeHosts(v []string) Option {
+	return func(c *config) {
+		c.ScopeHosts = v
+	}
+}
+
 // WithSubdomains enables subdomains scanning.
 func WithSubdomains(v bool) Option {
 	return func(c *config) {
 		c.Subdomains = v
 	}
 }
+
+// WithCheckpoint sets file name, where crawling state is saved - on shutdown and periodically.
+func WithCheckpoint(v string) Option {
+	return func(c *config) {
+		c.Checkpoint = v
+	}
+}
+
+// WithCheckpointInterval sets interval for periodic checkpoint saves (0 - only on shutdown).
+func WithCheckpointInterval(v time.Duration) Option {
+	return func(c *config) {
+		c.CheckpointEvery = v
+	}
+}
+
+// WithFrontierSize sets maximum count of crawl tasks to keep in memory, the rest is spilled to disk.
+func WithFrontierSize(v int) Option {
+	return func(c *config) {
+		c.FrontierSize = v
+	}
+}
+
+// WithLinkGraph enables links graph recording, see Crawler.Graph.
+func WithLinkGraph(v bool) Option {
+	return func(c *config) {
+		c.Graph = v
+	}
+}
+
+// WithMaxPages sets maximum count of pages to fetch (0 - unlimited).
+func WithMaxPages(v int) Option {
+	return func(c *config) {
+		c.MaxPages = v
+	}
+}
+
+// WithMaxURLs sets maximum count of urls to report (0 - unlimited).
+func WithMaxURLs(v int) Option {
+	return func(c *config) {
+		c.MaxURLs = v
+	}
+}
+
+// WithMaxBytes sets maximum total size of response bodies to read (0 - unlimited).
+func WithMaxBytes(v int64) Option {
+	return func(c *config) {
+		c.MaxBytes = v
+	}
+}
+
+// WithMaxDuration sets maximum crawl duration (0 - unlimited).
+func WithMaxDuration(v time.Duration) Option {
+	return func(c *config) {
+		c.MaxTime = v
+	}
+}
@@ -10,10 +10,21 @@ const (
 	DefaultRobotsPolicy = "ignore"
 	// DefaultDirsPolicy is a default policy name for non-resource URLs.
 	DefaultDirsPolicy = "show"
+	// DefaultScopePolicy is a default policy name for crawl scope.
+	DefaultScopePolicy = "path"
 )
 
-// ErrUnknownPolicy is returned when requested policy unknown.
-var ErrUnknownPolicy = errors.New("unknown policy")
+var (
+	// ErrUnknownPolicy is returned when requested policy unknown.
+	ErrUnknownPolicy = errors.New("unknown policy")
+	// ErrUnknownLinkType is returned when link type cannot be parsed.
+	ErrUnknownLinkType = errors.New("unknown link type")
+	// ErrUnknownSeed is returned when restored task refers to seed, that was not given.
+	ErrUnknownSeed = errors.New("unknown seed")
+	// ErrCheckResume is returned when checkpoint of links check is restored: check results (broken
+	// links and pages, they were found at) are not saved, so check cannot be continued.
+	ErrCheckResume = errors.New("links check cannot be resumed")
+)
 
 // RobotsPolicy is a policy for robots.txt.
 type RobotsPolicy byte
@@ -39,6 +50,36 @@ const (
 	DirsOnly DirsPolicy = 2
 )
 
+// ScopePolicy is a policy for crawl scope.
+type ScopePolicy byte
+
+const (
+	// ScopePath crawls only urls on starting host, below starting path.
+	ScopePath ScopePolicy = 0
+	// ScopeHost crawls any url on starting host.
+	ScopeHost ScopePolicy = 1
+	// ScopeDomain crawls any url within registrable domain of starting host.
+	ScopeDomain ScopePolicy = 2
+	// ScopeList crawls any url on starting host, or on one of given hosts.
+	ScopeList ScopePolicy = 3
+)
+
+var scopeNames = [...]string{
+	ScopePath:   "path",
+	ScopeHost:   "host",
+	ScopeDomain: "domain",
+	ScopeList:   "list",
+}
+
+// String returns textual representation for scope policy.
+func (p ScopePolicy) String() (rv string) {
+	if int(p) < len(scopeNames) {
+		return scopeNames[p]
+	}
+
+	return "unknown"
+}
+
 // ParseRobotsPolicy parses robots policy from string.
 func ParseRobotsPolicy(s string) (p RobotsPolicy, err error) {
 	switch strings.ToLower(s) {
@@ -74,3 +115,14 @@ func ParseDirsPolicy(s string) (p DirsPolicy, err error) {
 
 	return p, nil
 }
+
+// ParseScopePolicy parses scope policy from string.
+func ParseScopePolicy(s string) (p ScopePolicy, err error) {
+	for i, n := range scopeNames {
+		if n == strings.ToLower(s) {
+			return ScopePolicy(i), nil
+		}
+	}
+
+	return p, ErrUnknownPolicy
+}
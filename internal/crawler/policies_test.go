@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -82,3 +83,47 @@ func TestParseDirsPolicyErr(t *testing.T) {
 		t.Error("unexpected error")
 	}
 }
+
+func TestParseScopePolicy(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Have string
+		Want ScopePolicy
+	}
+
+	cases := []testCase{
+		{Have: "path", Want: ScopePath},
+		{Have: "HOST", Want: ScopeHost},
+		{Have: "domain", Want: ScopeDomain},
+		{Have: "list", Want: ScopeList},
+	}
+
+	for i, tc := range cases {
+		got, err := ParseScopePolicy(tc.Have)
+		if err != nil {
+			t.Errorf("case[%d]: got error: %v", i+1, err)
+		}
+
+		if got != tc.Want {
+			t.Errorf("case[%d]: unexpected result want: %d got: %d", i+1, tc.Want, got)
+		}
+
+		if got.String() != strings.ToLower(tc.Have) {
+			t.Errorf("case[%d]: unexpected name: %s", i+1, got)
+		}
+	}
+
+	if ScopePolicy(100).String() != "unknown" {
+		t.Error("unexpected name for unknown policy")
+	}
+}
+
+func TestParseScopePolicyErr(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseScopePolicy("dsf")
+	if !errors.Is(err, ErrUnknownPolicy) {
+		t.Error("unexpected error")
+	}
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/atom"
+
+	"github.com/s0rg/crawley/internal/client"
+)
+
+const redirectArrow = " -> "
+
+// Redirect is a single hop of redirect chain.
+type Redirect struct {
+	// URL is a redirect target.
+	URL string `json:"url"`
+	// Status is a status code of response, that caused redirect.
+	Status int `json:"status"`
+}
+
+// RedirectChain returns textual representation of redirect chain, as: "url -> 301 -> target",
+// or just url, if there were no redirects.
+func (r *Result) RedirectChain() (rv string) {
+	var sb strings.Builder
+
+	sb.WriteString(r.URL)
+
+	for _, h := range r.Redirects {
+		sb.WriteString(redirectArrow)
+		sb.WriteString(strconv.Itoa(h.Status))
+		sb.WriteString(redirectArrow)
+		sb.WriteString(h.URL)
+	}
+
+	return sb.String()
+}
+
+// trackRedirects returns redirect policy for task: only redirects, that crawl scope allows, are followed,
+// followed targets are reported as found (and processed) urls, so they wont be crawled once again.
+func (c *Crawler) trackRedirects(task *crawlTask, hops *[]Redirect) client.RedirectFunc {
+	return func(status int, to *url.URL) (follow bool) {
+		s, us := c.seeds[task.Seed], to.String()
+
+		if !canCrawl(s.URL, to, c.cfg.Depth, c.scope) ||
+			s.robots.Forbidden(to.Path) ||
+			c.isIgnored(us) || !c.avoid.Allow(us) || !c.crawl.Allow(us) {
+			return false
+		}
+
+		*hops = append(*hops, Redirect{URL: us, Status: status})
+
+		c.resultCh <- crawlResult{
+			Result: Result{
+				URL:    us,
+				Source: task.URI,
+				Tag:    atom.A,
+				Depth:  task.Depth,
+				Type:   LinkPage,
+			},
+			Hash: urlhash(us),
+			Seed: task.Seed,
+		}
+
+		return true
+	}
+}
+
+// redirectStopped records redirect, that was not followed (by scope, or hops limit), its target
+// is reported as found link, so it is crawled on its own, if allowed.
+func (c *Crawler) redirectStopped(task *crawlTask, hops *[]Redirect, resp *client.Response) {
+	u, ok := redirectTarget(task.URL, resp)
+	if !ok {
+		return
+	}
+
+	us := u.String()
+
+	*hops = append(*hops, Redirect{URL: us, Status: resp.Code})
+
+	c.linkHandler(task, atom.A, us, LinkPage)
+}
+
+// redirectTarget returns target of redirect response, that was not followed, relative urls are resolved
+// against response url, or base, if it is unknown.
+func redirectTarget(base *url.URL, resp *client.Response) (u *url.URL, ok bool) {
+	loc := resp.Header.Get("Location")
+	if !isRedirect(resp.Code) || loc == "" {
+		return nil, false
+	}
+
+	if resp.URL != nil {
+		base = resp.URL
+	}
+
+	u, err := base.Parse(loc)
+	if err != nil {
+		return nil, false
+	}
+
+	return u, true
+}
+
+func isRedirect(status int) (yes bool) {
+	return status >= http.StatusMultipleChoices && status < http.StatusBadRequest
+}
@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/net/html/atom"
+)
+
+// LinkType is a kind of found url.
+type LinkType byte
+
+const (
+	// LinkPage is a link to page (a, iframe, form, etc...).
+	LinkPage LinkType = 0
+	// LinkStatic is a link to static resource (images, scripts, styles, etc...).
+	LinkStatic LinkType = 1
+	// LinkSitemap is a link to sitemap.
+	LinkSitemap LinkType = 2
+	// LinkRobots is a link, found in robots.txt rules.
+	LinkRobots LinkType = 3
+)
+
+var linkTypes = [...]string{
+	LinkPage:    "page",
+	LinkStatic:  "static",
+	LinkSitemap: "sitemap",
+	LinkRobots:  "robots",
+}
+
+// String returns textual representation for link type.
+func (l LinkType) String() (rv string) {
+	if int(l) < len(linkTypes) {
+		return linkTypes[l]
+	}
+
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l LinkType) MarshalText() (rv []byte, err error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *LinkType) UnmarshalText(v []byte) (err error) {
+	for i, s := range linkTypes {
+		if s == string(v) {
+			*l = LinkType(i)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownLinkType, v)
+}
+
+// Result holds single found url and all, that crawler knows about it.
+type Result struct {
+	// URL is a found url.
+	URL string `json:"url"`
+	// Source is an url of page (or file), where URL was found.
+	Source string `json:"source,omitempty"`
+	// ContentType is a value of Content-Type header, if URL was requested.
+	ContentType string `json:"content_type,omitempty"`
+	// Tag is a html tag, URL was found in.
+	Tag atom.Atom `json:"tag,omitempty"`
+	// Depth is a count of link hops from the starting url.
+	Depth int `json:"depth"`
+	// Status is an HTTP status code, if URL was requested.
+	Status int `json:"status,omitempty"`
+	// Reason is an HTTP reason phrase, i.e. "Not Found", if URL was requested.
+	Reason string `json:"reason,omitempty"`
+	// Type is a kind of found url.
+	Type LinkType `json:"type"`
+	// Redirects is a redirect chain, if URL was requested and redirected.
+	Redirects []Redirect `json:"redirects,omitempty"`
+}
+
+// resultAlias has all Result fields, but none of its methods.
+type resultAlias Result
+
+// MarshalJSON implements json.Marshaler, tag is encoded by its name.
+func (r *Result) MarshalJSON() (rv []byte, err error) {
+	rv, err = json.Marshal(struct {
+		*resultAlias
+
+		Tag string `json:"tag,omitempty"`
+	}{
+		resultAlias: (*resultAlias)(r),
+		Tag:         r.Tag.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	return rv, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Result) UnmarshalJSON(v []byte) (err error) {
+	tmp := struct {
+		*resultAlias
+
+		Tag string `json:"tag,omitempty"`
+	}{
+		resultAlias: (*resultAlias)(r),
+	}
+
+	if err = json.Unmarshal(v, &tmp); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+
+	r.Tag = atom.Lookup([]byte(tmp.Tag))
+
+	return nil
+}
+
+// ResultHandler is a callback for crawling results.
+type ResultHandler func(*Result)
+
+// URLHandler adapts plain-string callback to ResultHandler.
+func URLHandler(cb func(string)) ResultHandler {
+	return func(r *Result) {
+		cb(r.URL)
+	}
+}
+
+func linkType(a atom.Atom, s string) (rv LinkType) {
+	switch {
+	case isSitemap(s):
+		return LinkSitemap
+	case a == atom.A || a == atom.Iframe || a == atom.Frame || a == atom.Form:
+		return LinkPage
+	}
+
+	return LinkStatic
+}
@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html/atom"
+)
+
+func TestLinkType(t *testing.T) {
+	t.Parallel()
+
+	for _, l := range []LinkType{LinkPage, LinkStatic, LinkSitemap, LinkRobots} {
+		txt, _ := l.MarshalText()
+
+		var got LinkType
+
+		if err := got.UnmarshalText(txt); err != nil {
+			t.Fatalf("unmarshal %s: %v", txt, err)
+		}
+
+		if got != l {
+			t.Errorf("unexpected value for %s", txt)
+		}
+	}
+
+	if LinkType(100).String() != "unknown" {
+		t.Error("unexpected unknown value")
+	}
+
+	var l LinkType
+
+	if err := l.UnmarshalText([]byte("foo")); !errors.Is(err, ErrUnknownLinkType) {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestLinkTypeFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag  atom.Atom
+		uri  string
+		want LinkType
+	}{
+		{atom.A, "http://test/page", LinkPage},
+		{atom.Iframe, "http://test/frame", LinkPage},
+		{atom.Form, "http://test/form", LinkPage},
+		{atom.Img, "http://test/pic.png", LinkStatic},
+		{atom.Script, "http://test/app.js", LinkStatic},
+		{atom.A, "http://test/sitemap.xml", LinkSitemap},
+	}
+
+	for _, tc := range tests {
+		if got := linkType(tc.tag, tc.uri); got != tc.want {
+			t.Errorf("%s: got %s want %s", tc.uri, got, tc.want)
+		}
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	t.Parallel()
+
+	r := &Result{
+		URL:         "http://test/a",
+		Source:      "http://test/",
+		ContentType: "text/html",
+		Tag:         atom.Iframe,
+		Depth:       2,
+		Status:      200,
+		Reason:      "OK",
+		Type:        LinkPage,
+		Redirects:   []Redirect{{URL: "http://test/b", Status: 301}},
+	}
+
+	buf, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal("marshal:", err)
+	}
+
+	for _, s := range []string{
+		`"tag":"iframe"`, `"type":"page"`, `"status":200`, `"reason":"OK"`,
+		`"redirects":[{"url":"http://test/b","status":301}]`,
+	} {
+		if !strings.Contains(string(buf), s) {
+			t.Errorf("no %s in %s", s, buf)
+		}
+	}
+
+	var got Result
+
+	if err = json.Unmarshal(buf, &got); err != nil {
+		t.Fatal("unmarshal:", err)
+	}
+
+	if !reflect.DeepEqual(got, *r) {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	if err = json.Unmarshal([]byte(`{"type":"foo"}`), &got); err == nil {
+		t.Error("no error")
+	}
+
+	if buf, _ = json.Marshal(&Result{URL: "http://test/"}); strings.Contains(string(buf), "tag") {
+		t.Errorf("unexpected tag in %s", buf)
+	}
+}
+
+func TestURLHandler(t *testing.T) {
+	t.Parallel()
+
+	var got string
+
+	URLHandler(func(s string) {
+		got = s
+	})(&Result{URL: "http://test/"})
+
+	if got != "http://test/" {
+		t.Error("unexpected value:", got)
+	}
+}
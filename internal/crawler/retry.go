@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultRetries is a recommended count of retries for failed requests.
+	DefaultRetries = 2
+	// DefaultRetryWait is a default base interval between retries.
+	DefaultRetryWait = time.Second
+)
+
+const (
+	maxRetries   = 10
+	maxRetryWait = time.Minute
+)
+
+// Failure describes url, that could not be crawled, due to network or server errors.
+type Failure struct {
+	URL    string `json:"url"`
+	Error  string `json:"error"`
+	Status int    `json:"status,omitempty"`
+	Tries  int    `json:"tries"`
+}
+
+// transient reports, if request failure is worth retrying.
+func transient(status int, err error) (yes bool) {
+	if err == nil {
+		return false
+	}
+
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// backoff returns jittered exponential delay for given try.
+func backoff(base time.Duration, try int) (d time.Duration) {
+	d = maxRetryWait
+
+	if shift := max(0, try-1); shift < maxRetries {
+		d = min(d, base<<shift)
+	}
+
+	half := d / 2
+
+	return half + rand.N(half+1) //nolint:gosec // jitter does not need crypto
+}
+
+// retry puts task back to queue, after backoff, without blocking workers.
+func (c *Crawler) retry(ctx context.Context, task *crawlTask) {
+	task.Tries++
+
+	d := backoff(c.cfg.RetryWait, task.Tries)
+
+	go func() {
+		// on cancel task is pushed at once, to be drained
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+
+		c.frontier.Push(task)
+	}()
+}
@@ -0,0 +1,48 @@
+package crawler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTransient(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test")
+
+	for _, tc := range []struct {
+		err    error
+		status int
+		want   bool
+	}{
+		{nil, 0, false},
+		{nil, http.StatusOK, false},
+		{errTest, 0, true},
+		{errTest, http.StatusNotFound, false},
+		{errTest, http.StatusTooManyRequests, true},
+		{errTest, http.StatusInternalServerError, true},
+		{errTest, http.StatusGatewayTimeout, true},
+	} {
+		if got := transient(tc.status, tc.err); got != tc.want {
+			t.Errorf("%d %v: want: %v got: %v", tc.status, tc.err, tc.want, got)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	const base = 100 * time.Millisecond
+
+	for try := range 20 {
+		want := min(maxRetryWait, base<<max(0, min(try-1, maxRetries)))
+
+		for range 10 {
+			if d := backoff(base, try); d < want/2 || d > want {
+				t.Fatalf("try %d: unexpected delay: %s, want: %s", try, d, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package crawler
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/s0rg/set"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// scope decides, which urls are allowed to be crawled, relative to starting url.
+type scope struct {
+	hosts      set.Set[string]
+	policy     ScopePolicy
+	subdomains bool
+}
+
+func newScope(cfg *config) (s *scope) {
+	s = &scope{
+		hosts:      make(set.Unordered[string]),
+		policy:     cfg.Scope,
+		subdomains: cfg.Subdomains,
+	}
+
+	for _, h := range cfg.ScopeHosts {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+
+		if name, _, err := net.SplitHostPort(h); err == nil {
+			h = name
+		}
+
+		s.hosts.Add(normalizeHost(h))
+	}
+
+	return s
+}
+
+// Host reports if host of b is in scope for base url a, ports are compared only for same hosts.
+func (s *scope) Host(a, b *url.URL) (yes bool) {
+	ah, ap := hostPort(a)
+	bh, bp := hostPort(b)
+
+	if ah == bh && ap == bp {
+		return true
+	}
+
+	switch s.policy {
+	case ScopeDomain:
+		return ah == bh || sameDomain(ah, bh)
+	case ScopeList:
+		if s.hosts.Has(bh) {
+			return true
+		}
+
+		if s.subdomains {
+			for h := range s.hosts.Iter {
+				if isSubdomain(h, bh) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	return s.subdomains && ah != bh && isSubdomain(ah, bh)
+}
+
+// Depth returns path depth of sub relative to base, ok is false, if sub is out of scope.
+func (s *scope) Depth(base, sub string) (n int, ok bool) {
+	if n, ok = relativeDepth(base, sub); ok || s.policy == ScopePath {
+		return n, ok
+	}
+
+	return pathDistance(base, sub), true
+}
+
+// hostPort returns normalized host name and port of url, default ports are omitted.
+func hostPort(u *url.URL) (host, port string) {
+	host, port = normalizeHost(u.Hostname()), u.Port()
+
+	if port == defaultPorts[strings.ToLower(u.Scheme)] {
+		port = ""
+	}
+
+	return host, port
+}
+
+// normalizeHost lowercases host, removes trailing dot and converts IDN to punycode.
+func normalizeHost(h string) (rv string) {
+	h = strings.TrimSuffix(strings.ToLower(h), ".")
+
+	if ip := net.ParseIP(h); ip != nil {
+		return ip.String()
+	}
+
+	if rv, err := idna.Lookup.ToASCII(h); err == nil {
+		return rv
+	}
+
+	return h
+}
+
+// registrableDomain returns eTLD+1 for host, ok is false for ip addresses and public suffixes.
+func registrableDomain(host string) (rv string, ok bool) {
+	if net.ParseIP(host) != nil {
+		return "", false
+	}
+
+	rv, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return "", false
+	}
+
+	return rv, true
+}
+
+func sameDomain(a, b string) (yes bool) {
+	da, ok := registrableDomain(a)
+	if !ok {
+		return false
+	}
+
+	db, ok := registrableDomain(b)
+
+	return ok && da == db
+}
+
+// isSubdomain reports if host is base itself or its subdomain, both must be within same
+// registrable domain, "www." prefix of base is ignored, so www.site.com and site.com are equal.
+// Single-label bases (e.g. localhost) have no registrable domain, and only suffix is checked.
+func isSubdomain(base, host string) (yes bool) {
+	if !strings.Contains(base, ".") && net.ParseIP(base) == nil {
+		return strings.HasSuffix(host, "."+base)
+	}
+
+	if !sameDomain(base, host) {
+		return false
+	}
+
+	const www = "www."
+
+	if tmp := strings.TrimPrefix(base, www); tmp != base && sameDomain(tmp, host) {
+		base = tmp
+	}
+
+	return host == base || strings.HasSuffix(host, "."+base)
+}
+
+// pathDistance returns count of sub path segments, below its common parent with base.
+func pathDistance(base, sub string) (n int) {
+	var (
+		bs = pathSegments(base)
+		ss = pathSegments(sub)
+		i  int
+	)
+
+	for i < len(bs) && i < len(ss) && bs[i] == ss[i] {
+		i++
+	}
+
+	return len(ss) - i
+}
+
+func pathSegments(p string) (rv []string) {
+	return strings.FieldsFunc(path.Clean(p), func(r rune) bool {
+		return r == '/'
+	})
+}
+
+func canCrawl(a, b *url.URL, d int, s *scope) (yes bool) {
+	if !s.Host(a, b) {
+		return false
+	}
+
+	var apath, bpath string
+
+	if apath = a.Path; apath == "" {
+		apath = "/"
+	}
+
+	if bpath = b.Path; bpath == "" {
+		bpath = "/"
+	}
+
+	depth, found := s.Depth(apath, bpath)
+	if !found {
+		return false
+	}
+
+	if d >= 0 && depth > d {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,191 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCanCrawl(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		b          *url.URL
+		u          *url.URL
+		d          int
+		subdomains bool
+	}
+
+	base, _ := url.Parse("http://test/some/path")
+	badh, _ := url.Parse("http://other/path")
+	url0, _ := url.Parse("http://test/some")
+	url1, _ := url.Parse("http://test/some/path/even")
+	url2, _ := url.Parse("http://test/some/path/even/more")
+	url3, _ := url.Parse("http://test")
+	url4, _ := url.Parse("http://abc.test/some")
+	url5, _ := url.Parse("http://abc.test/some/path")
+	url6, _ := url.Parse("http://abc.test/some/path/even")
+
+	tests := []struct {
+		name    string
+		args    args
+		wantYes bool
+	}{
+		{"url0-1", args{b: base, u: url0, d: 1, subdomains: false}, false},
+		{"url1-0", args{b: base, u: url1, d: 0, subdomains: false}, false},
+		{"url1-1", args{b: base, u: url1, d: 1, subdomains: false}, true},
+		{"url2-0", args{b: base, u: url2, d: 0, subdomains: false}, false},
+		{"url2-1", args{b: base, u: url2, d: 1, subdomains: false}, false},
+		{"url2-2", args{b: base, u: url2, d: 2, subdomains: false}, true},
+		{"url2-3", args{b: base, u: url2, d: 3, subdomains: false}, true},
+		{"badh-1", args{b: base, u: badh, d: 1, subdomains: false}, false},
+		{"url2-0-1", args{b: base, u: url0, d: -1, subdomains: false}, false},
+		{"url2-1-1", args{b: base, u: url1, d: -1, subdomains: false}, true},
+		{"url2-2-1", args{b: base, u: url2, d: -1, subdomains: false}, true},
+		{"url3-3", args{b: base, u: url3, d: 0, subdomains: false}, false},
+		{"url4-1", args{b: base, u: url4, d: 1000, subdomains: true}, false},
+		{"url5-1", args{b: base, u: url5, d: -1, subdomains: true}, true},
+		{"url5-2", args{b: base, u: url5, d: -1, subdomains: false}, false},
+		{"url6-1", args{b: base, u: url6, d: 1, subdomains: true}, true},
+		{"url6-2", args{b: base, u: url6, d: 0, subdomains: true}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newScope(&config{Subdomains: tc.args.subdomains})
+
+			if gotYes := canCrawl(tc.args.b, tc.args.u, tc.args.d, s); gotYes != tc.wantYes {
+				t.Errorf("canCrawl() = %v, want %v", gotYes, tc.wantYes)
+			}
+		})
+	}
+}
+
+func TestCanCrawlScope(t *testing.T) {
+	t.Parallel()
+
+	base, _ := url.Parse("http://www.test.com/blog/post-1")
+
+	type testCase struct {
+		URL    string
+		Scope  ScopePolicy
+		Hosts  []string
+		Depth  int
+		Subdom bool
+		Want   bool
+	}
+
+	cases := []testCase{
+		{URL: "http://www.test.com/about", Scope: ScopePath, Depth: -1, Want: false},
+		{URL: "http://www.test.com/about", Scope: ScopeHost, Depth: -1, Want: true},
+		{URL: "http://www.test.com/about/team", Scope: ScopeHost, Depth: 1, Want: false},
+		{URL: "http://www.test.com/blog/post-2", Scope: ScopeHost, Depth: 1, Want: true},
+		{URL: "http://www.test.com/blog/post-1/comments", Scope: ScopeHost, Depth: 1, Want: true},
+		{URL: "http://api.test.com/about", Scope: ScopeHost, Depth: -1, Want: false},
+		{URL: "http://api.test.com/about", Scope: ScopeHost, Depth: -1, Subdom: true, Want: true},
+		{URL: "http://api.www.test.com/about", Scope: ScopeHost, Depth: -1, Subdom: true, Want: true},
+		{URL: "http://api.test.com/about", Scope: ScopeDomain, Depth: -1, Want: true},
+		{URL: "http://TEST.com/", Scope: ScopeDomain, Depth: -1, Want: true},
+		{URL: "http://other.com/", Scope: ScopeDomain, Depth: -1, Want: false},
+		{URL: "http://other.com/", Scope: ScopeList, Hosts: []string{"other.com"}, Depth: -1, Want: true},
+		{URL: "http://www.test.com/", Scope: ScopeList, Hosts: []string{"other.com"}, Depth: -1, Want: true},
+		{URL: "http://cdn.other.com/", Scope: ScopeList, Hosts: []string{"other.com"}, Depth: -1, Want: false},
+		{URL: "http://cdn.other.com/", Scope: ScopeList, Hosts: []string{" other.com "}, Depth: -1, Subdom: true, Want: true},
+		{URL: "http://api.test.com/", Scope: ScopeList, Hosts: []string{"other.com"}, Depth: -1, Want: false},
+	}
+
+	for i, tc := range cases {
+		u, _ := url.Parse(tc.URL)
+
+		s := newScope(&config{Scope: tc.Scope, ScopeHosts: tc.Hosts, Subdomains: tc.Subdom})
+
+		if got := canCrawl(base, u, tc.Depth, s); got != tc.Want {
+			t.Errorf("case[%d] %s: want: %v got: %v", i+1, tc.URL, tc.Want, got)
+		}
+	}
+}
+
+func TestPathDistance(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Base string
+		Sub  string
+		Want int
+	}
+
+	cases := []testCase{
+		{Base: "/", Sub: "/", Want: 0},
+		{Base: "/a/b", Sub: "/a/c", Want: 1},
+		{Base: "/a/b", Sub: "/c", Want: 1},
+		{Base: "/a/b", Sub: "/c/d/e", Want: 3},
+		{Base: "/a/b", Sub: "/a/b/c/", Want: 1},
+	}
+
+	for i, tc := range cases {
+		if got := pathDistance(tc.Base, tc.Sub); got != tc.Want {
+			t.Errorf("case[%d]: want: %d got: %d", i+1, tc.Want, got)
+		}
+	}
+}
+
+func TestScopeHosts(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Base   string
+		URL    string
+		Scope  ScopePolicy
+		Subdom bool
+		Want   bool
+	}
+
+	cases := []testCase{
+		{Base: "http://a.co.uk/", URL: "http://b.co.uk/", Scope: ScopeDomain, Want: false},
+		{Base: "http://a.co.uk/", URL: "http://b.co.uk/", Subdom: true, Want: false},
+		{Base: "http://www.a.co.uk/", URL: "http://cdn.a.co.uk/", Scope: ScopeDomain, Want: true},
+		{Base: "http://www.a.co.uk/", URL: "http://cdn.a.co.uk/", Subdom: true, Want: true},
+		{Base: "http://site.com/", URL: "http://www.site.com/", Subdom: true, Want: true},
+		{Base: "http://www.site.com/", URL: "http://site.com/", Subdom: true, Want: true},
+		{Base: "http://site.com/", URL: "http://site.com:8080/", Want: false},
+		{Base: "http://site.com/", URL: "http://site.com:80/", Want: true},
+		{Base: "https://site.com/", URL: "http://site.com/", Want: true},
+		{Base: "http://site.com:8080/", URL: "http://api.site.com:9090/", Subdom: true, Want: true},
+		{Base: "http://site.com:8080/", URL: "http://api.site.com:9090/", Scope: ScopeDomain, Want: true},
+		{Base: "http://SITE.com./", URL: "http://site.com/", Want: true},
+		{Base: "http://пример.рф/", URL: "http://xn--e1afmkfd.xn--p1ai/", Want: true},
+		{Base: "http://www.пример.рф/", URL: "http://api.xn--e1afmkfd.xn--p1ai/", Scope: ScopeDomain, Want: true},
+		{Base: "http://[::1]:8080/", URL: "http://[0:0::1]:8080/", Want: true},
+		{Base: "http://[::1]:8080/", URL: "http://[::2]:8080/", Scope: ScopeDomain, Want: false},
+		{Base: "http://127.0.0.1/", URL: "http://1.127.0.0.1/", Subdom: true, Want: false},
+		{Base: "http://localhost/", URL: "http://api.localhost/", Subdom: true, Want: true},
+	}
+
+	for i, tc := range cases {
+		a, _ := url.Parse(tc.Base)
+		b, _ := url.Parse(tc.URL)
+
+		s := newScope(&config{Scope: tc.Scope, Subdomains: tc.Subdom})
+
+		if got := s.Host(a, b); got != tc.Want {
+			t.Errorf("case[%d] %s -> %s: want: %v got: %v", i+1, tc.Base, tc.URL, tc.Want, got)
+		}
+	}
+}
+
+func TestScopeListHosts(t *testing.T) {
+	t.Parallel()
+
+	s := newScope(&config{Scope: ScopeList, ScopeHosts: []string{"API.Site.com:8080", "пример.рф", ""}})
+
+	a, _ := url.Parse("http://site.com/")
+
+	for _, v := range []string{"http://api.site.com/", "http://api.site.com:9090/", "http://xn--e1afmkfd.xn--p1ai/"} {
+		b, _ := url.Parse(v)
+
+		if !s.Host(a, b) {
+			t.Error("not in scope:", v)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/s0rg/crawley/internal/robots"
+)
+
+// seed is a starting url, it has its own scope base and robots.txt rules.
+type seed struct {
+	URL    *url.URL
+	robots *robots.TXT
+}
+
+func parseSeeds(uris []string) (rv []*seed, err error) {
+	rv = make([]*seed, len(uris))
+
+	for i, uri := range uris {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+
+		rv[i] = &seed{URL: u, robots: robots.AllowALL()}
+	}
+
+	return rv, nil
+}
+
+func seedURLs(seeds []*seed) (rv []*url.URL) {
+	rv = make([]*url.URL, len(seeds))
+
+	for i, s := range seeds {
+		rv[i] = s.URL
+	}
+
+	return rv
+}
+
+// authHosts returns hosts, credentials are sent to: seeds hosts and extra ones.
+func authHosts(seeds []*seed, extra []string) (rv []string) {
+	rv = make([]string, 0, len(seeds)+len(extra))
+
+	for _, s := range seeds {
+		rv = append(rv, s.URL.Host)
+	}
+
+	return append(rv, extra...)
+}
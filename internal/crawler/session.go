@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"sync"
+
+	"github.com/s0rg/crawley/internal/rules"
+)
+
+const (
+	sessionWarmup  = 10 // pages, to measure typical response size
+	sessionRepeats = 5  // same-sized responses in a row, to consider session lost
+	sessionRatio   = 4  // how much smaller than average response should be
+)
+
+// DefaultAvoid holds patterns for links, that are likely to end session, they are not crawled (but printed).
+var DefaultAvoid = []string{
+	`re:(?i)(^|[^a-z])(log|sign)[-_]?(out|off)([^a-z]|$)`,
+	`re:(?i)(^|[^a-z])(end|destroy|kill)[-_]?session([^a-z]|$)`,
+}
+
+// sessionWatch detects "soft" session loss: site keeps answering 200, but with same (and much smaller,
+// than usual) page, i.e. login form or "session expired" message.
+type sessionWatch struct {
+	mu    sync.Mutex
+	total int64
+	pages int64
+	last  int64
+	same  int
+}
+
+// Check accounts page size, returns false, if session seems to be lost.
+func (s *sessionWatch) Check(size int64) (ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pages >= sessionWarmup && size*sessionRatio < s.total/s.pages {
+		if size != s.last {
+			s.last, s.same = size, 0
+		}
+
+		s.same++
+
+		return s.same < sessionRepeats
+	}
+
+	s.same = 0
+	s.total += size
+	s.pages++
+
+	return true
+}
+
+func prepareAvoid(cfg *config) (rv *rules.Set) {
+	switch {
+	case cfg.NoAvoid:
+		return nil
+	case len(cfg.Avoid) > 0:
+		return prepareRules(nil, cfg.Avoid)
+	}
+
+	return prepareRules(nil, DefaultAvoid)
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"testing"
+)
+
+func TestSessionWatch(t *testing.T) {
+	t.Parallel()
+
+	s := &sessionWatch{}
+
+	for range sessionWarmup {
+		if !s.Check(1000) {
+			t.Fatal("warmup - session lost")
+		}
+	}
+
+	// small, but different pages
+	for i := range sessionRepeats * 2 {
+		if !s.Check(int64(10 + i)) {
+			t.Fatal("different - session lost")
+		}
+	}
+
+	for range sessionRepeats - 1 {
+		if !s.Check(10) {
+			t.Fatal("repeats - session lost")
+		}
+	}
+
+	// usual page resets counter
+	if !s.Check(900) || !s.Check(10) {
+		t.Fatal("reset - session lost")
+	}
+
+	for range sessionRepeats - 2 {
+		_ = s.Check(10)
+	}
+
+	if s.Check(10) {
+		t.Error("session not lost")
+	}
+}
+
+func TestPrepareAvoid(t *testing.T) {
+	t.Parallel()
+
+	def := prepareAvoid(&config{})
+
+	for have, want := range map[string]bool{
+		"http://test/logout":                 false,
+		"http://test/user/log-out.php":       false,
+		"http://test/?action=signout":        false,
+		"http://test/sign_off":               false,
+		"http://test/account/end-session":    false,
+		"http://test/Logoff":                 false,
+		"http://test/blog-outline":           true,
+		"http://test/catalog/offers":         true,
+		"http://test/signing/outline":        true,
+		"http://test/sessions/end-of-summer": true,
+	} {
+		if got := def.Allow(have); got != want {
+			t.Errorf("%s: want: %v got: %v", have, want, got)
+		}
+	}
+
+	if prepareAvoid(&config{NoAvoid: true}) != nil {
+		t.Error("no avoid - not nil")
+	}
+
+	own := prepareAvoid(&config{Avoid: []string{"/bye"}})
+
+	if !own.Allow("http://test/logout") || own.Allow("http://test/bye") {
+		t.Error("own rules - unexpected result")
+	}
+}
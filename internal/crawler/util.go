@@ -1,6 +1,7 @@
 package crawler
 
 import (
+	"context"
 	"encoding/base64"
 	"hash/fnv"
 	"io"
@@ -10,12 +11,14 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/s0rg/set"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 
 	"github.com/s0rg/crawley/internal/links"
+	"github.com/s0rg/crawley/internal/rules"
 )
 
 const (
@@ -71,53 +74,19 @@ func prepareFilter(tags []string) links.TokenFilter {
 	}
 }
 
-func canCrawl(a, b *url.URL, d int, subdomains bool) (yes bool) {
-	if a.Host != b.Host {
-		if !subdomains {
-			return false
-		}
-
-		domainA := strings.Split(a.Host, ".")
-		domainB := strings.Split(b.Host, ".")
-
-		if len(domainA) >= len(domainB) {
-			// The base domain must be shorter than the found domain
-			return false
-		}
-
-		j := len(domainB) - 1
-
-		for i := len(domainA) - 1; i >= 0 && j >= 0; i-- {
-			// Traverse each domain from the end, to check if their top-level domain are the same
-			if domainA[i] != domainB[j] {
-				// not the same top-level host
-				return false
-			}
-
-			j--
-		}
-	}
-
-	var apath, bpath string
-
-	if apath = a.Path; apath == "" {
-		apath = "/"
-	}
+func prepareRules(include, exclude []string) (rv *rules.Set) {
+	rv, err := rules.New(include, exclude)
+	if err != nil {
+		log.Printf("[!] invalid rules: %v skipping...", err)
 
-	if bpath = b.Path; bpath == "" {
-		bpath = "/"
+		return nil
 	}
 
-	depth, found := relativeDepth(apath, bpath)
-	if !found {
-		return false
-	}
-
-	if d >= 0 && depth > d {
-		return false
-	}
+	return rv
+}
 
-	return true
+func canHop(hops, limit int) (yes bool) {
+	return limit <= 0 || hops <= limit
 }
 
 func relativeDepth(base, sub string) (n int, ok bool) {
@@ -241,3 +210,20 @@ func resolveRef(base, uri string) (rv string, ok bool) {
 
 	return rv, true
 }
+
+func sleepContext(ctx context.Context, d time.Duration) (ok bool) {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+	}
+
+	return true
+}
@@ -1,7 +1,6 @@
 package crawler
 
 import (
-	"net/url"
 	"testing"
 
 	"golang.org/x/net/html"
@@ -31,6 +30,18 @@ func TestPrepareFilter(t *testing.T) {
 	}
 }
 
+func TestPrepareRules(t *testing.T) {
+	t.Parallel()
+
+	if r := prepareRules([]string{"re:("}, nil); r != nil {
+		t.Error("invalid rules compiled")
+	}
+
+	if r := prepareRules(nil, []string{"*.png"}); r == nil || r.Allow("http://test/a.png") {
+		t.Error("unexpected rules")
+	}
+}
+
 func TestIsJS(t *testing.T) {
 	t.Parallel()
 
@@ -111,58 +122,24 @@ func BenchmarkDepth(b *testing.B) {
 	}
 }
 
-func TestCanCrawl(t *testing.T) {
+func TestCanHop(t *testing.T) {
 	t.Parallel()
 
-	type args struct {
-		b          *url.URL
-		u          *url.URL
-		d          int
-		subdomains bool
-	}
-
-	base, _ := url.Parse("http://test/some/path")
-	badh, _ := url.Parse("http://other/path")
-	url0, _ := url.Parse("http://test/some")
-	url1, _ := url.Parse("http://test/some/path/even")
-	url2, _ := url.Parse("http://test/some/path/even/more")
-	url3, _ := url.Parse("http://test")
-	url4, _ := url.Parse("http://abc.test/some")
-	url5, _ := url.Parse("http://abc.test/some/path")
-	url6, _ := url.Parse("http://abc.test/some/path/even")
-
 	tests := []struct {
-		name    string
-		args    args
-		wantYes bool
+		hops, limit int
+		want        bool
 	}{
-		{"url0-1", args{b: base, u: url0, d: 1, subdomains: false}, false},
-		{"url1-0", args{b: base, u: url1, d: 0, subdomains: false}, false},
-		{"url1-1", args{b: base, u: url1, d: 1, subdomains: false}, true},
-		{"url2-0", args{b: base, u: url2, d: 0, subdomains: false}, false},
-		{"url2-1", args{b: base, u: url2, d: 1, subdomains: false}, false},
-		{"url2-2", args{b: base, u: url2, d: 2, subdomains: false}, true},
-		{"url2-3", args{b: base, u: url2, d: 3, subdomains: false}, true},
-		{"badh-1", args{b: base, u: badh, d: 1, subdomains: false}, false},
-		{"url2-0-1", args{b: base, u: url0, d: -1, subdomains: false}, false},
-		{"url2-1-1", args{b: base, u: url1, d: -1, subdomains: false}, true},
-		{"url2-2-1", args{b: base, u: url2, d: -1, subdomains: false}, true},
-		{"url3-3", args{b: base, u: url3, d: 0, subdomains: false}, false},
-		{"url4-1", args{b: base, u: url4, d: 1000, subdomains: true}, false},
-		{"url5-1", args{b: base, u: url5, d: -1, subdomains: true}, true},
-		{"url5-2", args{b: base, u: url5, d: -1, subdomains: false}, false},
-		{"url6-1", args{b: base, u: url6, d: 1, subdomains: true}, true},
-		{"url6-2", args{b: base, u: url6, d: 0, subdomains: true}, false},
+		{hops: 0, limit: 0, want: true},
+		{hops: 100, limit: 0, want: true},
+		{hops: 2, limit: 2, want: true},
+		{hops: 3, limit: 2, want: false},
+		{hops: 1, limit: -1, want: true},
 	}
 
 	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-
-			if gotYes := canCrawl(tc.args.b, tc.args.u, tc.args.d, tc.args.subdomains); gotYes != tc.wantYes {
-				t.Errorf("canCrawl() = %v, want %v", gotYes, tc.wantYes)
-			}
-		})
+		if got := canHop(tc.hops, tc.limit); got != tc.want {
+			t.Errorf("canHop(%d, %d) = %v, want %v", tc.hops, tc.limit, got, tc.want)
+		}
 	}
 }
 
@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/s0rg/set"
+)
+
+// DefaultFormat is a default output format name for graph.
+const DefaultFormat = "dot"
+
+// ErrUnknownFormat is returned when requested format unknown.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Format is a graph output format.
+type Format byte
+
+const (
+	// FormatDOT is a Graphviz DOT format.
+	FormatDOT Format = 0
+	// FormatGraphML is a GraphML format.
+	FormatGraphML Format = 1
+	// FormatJSON is a JSON adjacency lists format.
+	FormatJSON Format = 2
+)
+
+// ParseFormat parses graph format from string.
+func ParseFormat(s string) (f Format, err error) {
+	switch strings.ToLower(s) {
+	case "dot":
+		f = FormatDOT
+	case "graphml":
+		f = FormatGraphML
+	case "json":
+		f = FormatJSON
+	default:
+		err = ErrUnknownFormat
+
+		return
+	}
+
+	return f, nil
+}
+
+type edge struct {
+	Src int
+	Dst int
+}
+
+// Graph holds directed links graph, nodes and edges are kept in order of appearance.
+type Graph struct {
+	index map[string]int
+	seen  set.Set[edge]
+	nodes []string
+	edges []edge
+}
+
+// New creates empty Graph.
+func New() *Graph {
+	return &Graph{
+		index: make(map[string]int),
+		seen:  make(set.Unordered[edge]),
+	}
+}
+
+// AddEdge adds src -> dst edge, duplicate edges are ignored.
+func (g *Graph) AddEdge(src, dst string) {
+	e := edge{Src: g.node(src), Dst: g.node(dst)}
+
+	if g.seen.Add(e) {
+		g.edges = append(g.edges, e)
+	}
+}
+
+// Nodes returns count of nodes.
+func (g *Graph) Nodes() int {
+	return len(g.nodes)
+}
+
+// Edges returns count of edges.
+func (g *Graph) Edges() int {
+	return len(g.edges)
+}
+
+// Write writes graph in given format.
+func (g *Graph) Write(w io.Writer, f Format) (err error) {
+	switch f {
+	case FormatDOT:
+		err = g.writeDOT(w)
+	case FormatGraphML:
+		err = g.writeGraphML(w)
+	case FormatJSON:
+		err = g.writeJSON(w)
+	default:
+		err = ErrUnknownFormat
+	}
+
+	return err
+}
+
+func (g *Graph) node(s string) (id int) {
+	id, ok := g.index[s]
+	if ok {
+		return id
+	}
+
+	id = len(g.nodes)
+	g.index[s] = id
+	g.nodes = append(g.nodes, s)
+
+	return id
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func (g *Graph) writeDOT(w io.Writer) (err error) {
+	var sb strings.Builder
+
+	sb.WriteString("digraph crawley {\n")
+
+	for i, n := range g.nodes {
+		fmt.Fprintf(&sb, "  n%d [label=\"%s\"];\n", i, dotEscaper.Replace(n))
+	}
+
+	for _, e := range g.edges {
+		fmt.Fprintf(&sb, "  n%d -> n%d;\n", e.Src, e.Dst)
+	}
+
+	sb.WriteString("}\n")
+
+	if _, err = io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func (g *Graph) writeGraphML(w io.Writer) (err error) {
+	var sb strings.Builder
+
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	sb.WriteString(`  <key id="url" for="node" attr.name="url" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <graph id="crawley" edgedefault="directed">` + "\n")
+
+	for i, n := range g.nodes {
+		fmt.Fprintf(&sb, `    <node id="n%d"><data key="url">`, i)
+
+		if err = xml.EscapeText(&sb, []byte(n)); err != nil {
+			return fmt.Errorf("escape: %w", err)
+		}
+
+		sb.WriteString("</data></node>\n")
+	}
+
+	for i, e := range g.edges {
+		fmt.Fprintf(&sb, `    <edge id="e%d" source="n%d" target="n%d"/>`+"\n", i, e.Src, e.Dst)
+	}
+
+	sb.WriteString("  </graph>\n</graphml>\n")
+
+	if _, err = io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+func (g *Graph) writeJSON(w io.Writer) (err error) {
+	adj := make(map[string][]string, len(g.nodes))
+
+	for _, n := range g.nodes {
+		adj[n] = []string{}
+	}
+
+	for _, e := range g.edges {
+		src := g.nodes[e.Src]
+		adj[src] = append(adj[src], g.nodes[e.Dst])
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err = enc.Encode(adj); err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	return nil
+}
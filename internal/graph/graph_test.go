@@ -0,0 +1,179 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type errWriter struct {
+	err error
+}
+
+func (ew *errWriter) Write(_ []byte) (n int, err error) {
+	return 0, ew.err
+}
+
+func testGraph() *Graph {
+	g := New()
+
+	g.AddEdge("http://test/", "http://test/a")
+	g.AddEdge("http://test/", "http://test/b?x=1&y=\"2\"")
+	g.AddEdge("http://test/a", "http://test/")
+	g.AddEdge("http://test/", "http://test/a")
+
+	return g
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		val  string
+		want Format
+		err  bool
+	}{
+		{"dot", FormatDOT, false},
+		{"GraphML", FormatGraphML, false},
+		{"json", FormatJSON, false},
+		{"foo", FormatDOT, true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseFormat(tc.val)
+		if (err != nil) != tc.err {
+			t.Fatalf("%s: unexpected error: %v", tc.val, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("%s: unexpected value: %d", tc.val, got)
+		}
+	}
+}
+
+func TestGraphCounts(t *testing.T) {
+	t.Parallel()
+
+	g := testGraph()
+
+	if g.Nodes() != 3 {
+		t.Error("unexpected nodes count:", g.Nodes())
+	}
+
+	if g.Edges() != 3 {
+		t.Error("unexpected edges count:", g.Edges())
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	if err := testGraph().Write(&buf, FormatDOT); err != nil {
+		t.Fatal(err)
+	}
+
+	s := buf.String()
+
+	for _, want := range []string{
+		"digraph crawley {",
+		`n0 [label="http://test/"];`,
+		`n2 [label="http://test/b?x=1&y=\"2\""];`,
+		"n0 -> n1;",
+		"n1 -> n0;",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("no %q in:\n%s", want, s)
+		}
+	}
+}
+
+func TestGraphGraphML(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	if err := testGraph().Write(&buf, FormatGraphML); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data string `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal("invalid xml:", err)
+	}
+
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 3 {
+		t.Fatal("unexpected counts")
+	}
+
+	if doc.Graph.Nodes[2].Data != "http://test/b?x=1&y=\"2\"" {
+		t.Error("unexpected node data:", doc.Graph.Nodes[2].Data)
+	}
+
+	if doc.Graph.Edges[2].Source != "n1" || doc.Graph.Edges[2].Target != "n0" {
+		t.Error("unexpected edge")
+	}
+}
+
+func TestGraphJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	if err := testGraph().Write(&buf, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var adj map[string][]string
+
+	if err := json.Unmarshal(buf.Bytes(), &adj); err != nil {
+		t.Fatal("invalid json:", err)
+	}
+
+	if len(adj) != 3 {
+		t.Fatal("unexpected nodes count")
+	}
+
+	if len(adj["http://test/"]) != 2 || len(adj["http://test/a"]) != 1 {
+		t.Error("unexpected edges")
+	}
+
+	if leaf, ok := adj["http://test/b?x=1&y=\"2\""]; !ok || len(leaf) != 0 {
+		t.Error("unexpected leaf")
+	}
+}
+
+func TestGraphWriteErrors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		g  = testGraph()
+		ew = &errWriter{err: errors.New("write error")}
+	)
+
+	for _, f := range []Format{FormatDOT, FormatGraphML, FormatJSON} {
+		if err := g.Write(ew, f); err == nil {
+			t.Errorf("format %d: no error", f)
+		}
+	}
+
+	if err := g.Write(&bytes.Buffer{}, Format(100)); !errors.Is(err, ErrUnknownFormat) {
+		t.Error("unexpected error:", err)
+	}
+}
@@ -18,6 +18,8 @@ const (
 	keyDATA   = "data"
 	keyACTION = "action"
 	keyPOSTER = "poster"
+	keyID     = "id"
+	keyNAME   = "name"
 )
 
 // HTMLHandler is a callback for found links.
@@ -26,14 +28,22 @@ type HTMLHandler func(atom.Atom, string)
 // TokenFilter is a callback for token filtration.
 type TokenFilter func(html.Token) bool
 
+// AnchorHandler is a callback for found anchors: element ids and names of a tags.
+type AnchorHandler func(string)
+
+// FragmentHandler is a callback for found links with fragment, it gets link (without fragment) and fragment.
+type FragmentHandler func(uri, fragment string)
+
 // HTMLParams holds config for ExtractHTML.
 type HTMLParams struct {
-	Filter       TokenFilter
-	HandleHTML   HTMLHandler
-	HandleStatic URLHandler
-	Brute        bool
-	ScanJS       bool
-	ScanCSS      bool
+	Filter         TokenFilter
+	HandleHTML     HTMLHandler
+	HandleStatic   URLHandler
+	HandleAnchor   AnchorHandler   // optional
+	HandleFragment FragmentHandler // optional
+	Brute          bool
+	ScanJS         bool
+	ScanCSS        bool
 }
 
 // AllowALL - stub that implements TokenFilter, it allows all tokens.
@@ -54,8 +64,18 @@ func ExtractHTML(r io.Reader, base *url.URL, cfg HTMLParams) {
 			return
 
 		case html.StartTagToken, html.SelfClosingTagToken:
-			if tok = tkns.Token(); cfg.Filter(tok) {
+			tok = tkns.Token()
+
+			if cfg.HandleAnchor != nil {
+				extractAnchors(&tok, cfg.HandleAnchor)
+			}
+
+			if cfg.Filter(tok) {
 				isJS, isCSS = extractToken(base, tok, &key, cfg.HandleHTML)
+
+				if cfg.HandleFragment != nil && tok.DataAtom == atom.A {
+					extractFragment(base, &tok, cfg.HandleFragment)
+				}
 			}
 
 		case html.TextToken:
@@ -76,6 +96,35 @@ func ExtractHTML(r io.Reader, base *url.URL, cfg HTMLParams) {
 	}
 }
 
+func extractAnchors(tok *html.Token, h AnchorHandler) {
+	for i := range tok.Attr {
+		a := &tok.Attr[i]
+
+		switch {
+		case a.Val == "":
+		case a.Key == keyID, a.Key == keyNAME && tok.DataAtom == atom.A:
+			h(a.Val)
+		}
+	}
+}
+
+func extractFragment(base *url.URL, tok *html.Token, h FragmentHandler) {
+	for i := range tok.Attr {
+		if a := &tok.Attr[i]; a.Key == keyHREF {
+			u, err := url.Parse(a.Val)
+			if err != nil || u.Fragment == "" {
+				return
+			}
+
+			if uri, ok := cleanURL(base, a.Val); ok {
+				h(uri, u.Fragment)
+			}
+
+			return
+		}
+	}
+}
+
 func extractComment(s string, h HTMLHandler) {
 	ss := bufio.NewScanner(strings.NewReader(s))
 	ss.Split(bufio.ScanWords)
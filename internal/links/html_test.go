@@ -392,3 +392,34 @@ func TestExtractAllowed(t *testing.T) {
 		t.Fatalf("unexpected result: %v", res)
 	}
 }
+
+func TestExtractAnchors(t *testing.T) {
+	t.Parallel()
+
+	const raw = `<html><h1 id="intro">1</h1><a name="old">2</a><div name="no">3</div><p id="">4</p>
+<a href="#intro">5</a><a href="page#install">6</a><a href="page">7</a><a href="#">8</a><img src="a.png#x"></html>`
+
+	var (
+		names []string
+		frags []string
+	)
+
+	ExtractHTML(bytes.NewBufferString(raw), testBase, HTMLParams{
+		Filter:     AllowALL,
+		HandleHTML: func(_ atom.Atom, _ string) {},
+		HandleAnchor: func(s string) {
+			names = append(names, s)
+		},
+		HandleFragment: func(uri, fragment string) {
+			frags = append(frags, uri+" "+fragment)
+		},
+	})
+
+	if !slices.Equal(names, []string{"intro", "old"}) {
+		t.Error("unexpected anchors:", names)
+	}
+
+	if !slices.Equal(frags, []string{"http://test/ intro", "http://test/page install"}) {
+		t.Error("unexpected fragments:", frags)
+	}
+}
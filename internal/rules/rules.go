@@ -0,0 +1,151 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	prefixRegex = "re:"
+	prefixGlob  = "glob:"
+	pathSep     = "/"
+)
+
+// ErrEmptyPattern is returned for empty patterns.
+var ErrEmptyPattern = errors.New("empty pattern")
+
+type matcher struct {
+	re   *regexp.Regexp
+	path bool // match against url path, not whole url
+}
+
+// Set holds include and exclude rules, url is allowed, if it matches any of include rules
+// (or there are none of them) and none of exclude rules.
+//
+// Patterns prefixed with "re:" are regular expressions, matched anywhere in url. All others
+// (optionally prefixed with "glob:") are globs, matched against whole url, or, if they
+// start with "/", against url path only: "*" matches any sequence of characters, "?" - any
+// single character.
+type Set struct {
+	include []matcher
+	exclude []matcher
+}
+
+// New compiles rules set from given patterns.
+func New(include, exclude []string) (s *Set, err error) {
+	s = &Set{}
+
+	if s.include, err = compileAll(include); err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+
+	if s.exclude, err = compileAll(exclude); err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+
+	return s, nil
+}
+
+// Empty reports if set has no rules, it is nil-safe.
+func (s *Set) Empty() (yes bool) {
+	return s == nil || (len(s.include) == 0 && len(s.exclude) == 0)
+}
+
+// Allow reports if uri passes rules, empty set allows anything.
+func (s *Set) Allow(uri string) (yes bool) {
+	if s.Empty() {
+		return true
+	}
+
+	var upath string
+
+	if u, err := url.Parse(uri); err == nil {
+		if upath = u.Path; upath == "" {
+			upath = pathSep
+		}
+	}
+
+	if len(s.include) > 0 && !matchAny(s.include, uri, upath) {
+		return false
+	}
+
+	return !matchAny(s.exclude, uri, upath)
+}
+
+func matchAny(ms []matcher, uri, upath string) (yes bool) {
+	for _, m := range ms {
+		v := uri
+		if m.path {
+			v = upath
+		}
+
+		if m.re.MatchString(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compileAll(patterns []string) (rv []matcher, err error) {
+	rv = make([]matcher, 0, len(patterns))
+
+	for _, p := range patterns {
+		m, err := compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", p, err)
+		}
+
+		rv = append(rv, m)
+	}
+
+	return rv, nil
+}
+
+func compile(p string) (m matcher, err error) {
+	if p = strings.TrimSpace(p); p == "" {
+		return m, ErrEmptyPattern
+	}
+
+	if expr, ok := strings.CutPrefix(p, prefixRegex); ok {
+		if m.re, err = regexp.Compile(expr); err != nil {
+			return m, fmt.Errorf("regex: %w", err)
+		}
+
+		return m, nil
+	}
+
+	p = strings.TrimPrefix(p, prefixGlob)
+	if p == "" {
+		return m, ErrEmptyPattern
+	}
+
+	m.path = strings.HasPrefix(p, pathSep)
+	m.re = regexp.MustCompile(globToRegex(p))
+
+	return m, nil
+}
+
+func globToRegex(p string) (rv string) {
+	var sb strings.Builder
+
+	sb.WriteByte('^')
+
+	for _, r := range p {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteByte('$')
+
+	return sb.String()
+}
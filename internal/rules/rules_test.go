@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetAllow(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Include []string
+		Exclude []string
+		URL     string
+		Want    bool
+	}
+
+	cases := []testCase{
+		{URL: "http://test/any", Want: true},
+		{Include: []string{"/docs/*"}, URL: "http://test/docs/a/b.html", Want: true},
+		{Include: []string{"/docs/*"}, URL: "http://test/blog/docs/a", Want: false},
+		{Include: []string{"/docs*"}, URL: "http://test/docs", Want: true},
+		{Include: []string{"/"}, URL: "http://test", Want: true},
+		{Include: []string{"*.pdf"}, URL: "http://test/files/a.pdf", Want: true},
+		{Include: []string{"glob:*.pdf"}, URL: "http://test/files/a.pdf?x=1", Want: false},
+		{Include: []string{"*.pdf", "*.doc"}, URL: "http://test/a.doc", Want: true},
+		{Include: []string{"/a?c"}, URL: "http://test/abc", Want: true},
+		{Include: []string{"/a?c"}, URL: "http://test/abbc", Want: false},
+		{Include: []string{"/a.c"}, URL: "http://test/abc", Want: false},
+		{Exclude: []string{"re:logout|signout"}, URL: "http://test/user/logout?x=1", Want: false},
+		{Exclude: []string{"re:logout|signout"}, URL: "http://test/user/profile", Want: true},
+		{Include: []string{"re:^https://"}, Exclude: []string{"*.png"}, URL: "https://test/a.png", Want: false},
+		{Include: []string{"re:^https://"}, Exclude: []string{"*.png"}, URL: "https://test/a.jpg", Want: true},
+		{Include: []string{"re:^https://"}, URL: "http://test/", Want: false},
+	}
+
+	for i, tc := range cases {
+		s, err := New(tc.Include, tc.Exclude)
+		if err != nil {
+			t.Fatalf("case[%d]: unexpected error: %v", i+1, err)
+		}
+
+		if got := s.Allow(tc.URL); got != tc.Want {
+			t.Errorf("case[%d]: %s want: %v got: %v", i+1, tc.URL, tc.Want, got)
+		}
+	}
+}
+
+func TestSetEmpty(t *testing.T) {
+	t.Parallel()
+
+	var s *Set
+
+	if !s.Empty() || !s.Allow("http://test/") {
+		t.Error("nil set is not empty")
+	}
+
+	s, _ = New(nil, []string{"*"})
+
+	if s.Empty() || s.Allow("http://test/") {
+		t.Error("non-empty set")
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New([]string{"re:("}, nil); err == nil {
+		t.Error("include - no error")
+	}
+
+	if _, err := New(nil, []string{" "}); !errors.Is(err, ErrEmptyPattern) {
+		t.Error("exclude - unexpected error:", err)
+	}
+
+	if _, err := New(nil, []string{"glob:"}); !errors.Is(err, ErrEmptyPattern) {
+		t.Error("glob - unexpected error:", err)
+	}
+}
@@ -27,13 +27,17 @@ func (s *Smart) Load(
 	var vals []string
 
 	for _, v := range s.values {
-		if v[0] == fileMarker {
+		switch {
+		case v == "":
+			// empty value (i.e. -seeds "") means nothing
+			continue
+		case v[0] == fileMarker:
 			if vals, err = loadFile(target, v[1:]); err != nil {
 				return
 			}
 
 			rv = append(rv, vals...)
-		} else {
+		default:
 			rv = append(rv, v)
 		}
 	}
@@ -109,3 +109,21 @@ func TestSmartLoadFSErrorDir(t *testing.T) {
 		t.Fatal("unexepected nil-error")
 	}
 }
+
+func TestSmartLoadEmpty(t *testing.T) {
+	t.Parallel()
+
+	var l Smart
+
+	_ = l.Set("")
+	_ = l.Set("a")
+
+	res, err := l.Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res) != 1 || res[0] != "a" {
+		t.Fatalf("unexpected result: %v", res)
+	}
+}